@@ -0,0 +1,143 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// arithOperExpr is one of the arithmetic operators: +, -, *, /, %
+type arithOperExpr struct {
+	exprL exprNode
+	exprR exprNode
+	oper  TokenValue
+	token ParserToken
+}
+
+func (ex *arithOperExpr) evaluate(sc *scope) (valueNode, error) {
+
+	left, right, err := evaluateOperands(sc, ex.exprL, ex.exprR)
+	if err != nil {
+		return nil, err
+	}
+	if left.isValue() == unknownValue {
+		return left, nil
+	}
+
+	if isTemporal(left.isValue()) || isTemporal(right.isValue()) {
+		return evaluateTimeArith(ex.token, ex.oper, left, right)
+	}
+
+	if !isNumeric(left.isValue()) || !isNumeric(right.isValue()) {
+		return nil, mismatchError(ex.token, left, right)
+	}
+
+	if isFloatPromoted(left, right) {
+		return ex.evaluateFloat(floatOf(left), floatOf(right))
+	}
+
+	var lvalue, rvalue int
+	left.value(&lvalue)
+	right.value(&rvalue)
+
+	var result int
+	switch ex.oper {
+	case token_ADD:
+		result = lvalue + rvalue
+	case token_SUB:
+		result = lvalue - rvalue
+	case token_MUL:
+		result = lvalue * rvalue
+	case token_DIV, token_MOD:
+		if rvalue == 0 {
+			return nil, newEvaluateErrorAt(ErrDivisionByZero, ex.token, "division by zero")
+		}
+		if ex.oper == token_DIV {
+			result = lvalue / rvalue
+		} else {
+			result = lvalue % rvalue
+		}
+	}
+
+	return &intValueExpr{val: result}, nil
+}
+
+func (ex *arithOperExpr) evaluateFloat(lvalue, rvalue float64) (valueNode, error) {
+
+	var result float64
+	switch ex.oper {
+	case token_ADD:
+		result = lvalue + rvalue
+	case token_SUB:
+		result = lvalue - rvalue
+	case token_MUL:
+		result = lvalue * rvalue
+	case token_DIV, token_MOD:
+		if rvalue == 0 {
+			return nil, newEvaluateErrorAt(ErrDivisionByZero, ex.token, "division by zero")
+		}
+		if ex.oper == token_DIV {
+			result = lvalue / rvalue
+		} else {
+			result = math.Mod(lvalue, rvalue)
+		}
+	}
+
+	return &floatValueExpr{val: result}, nil
+}
+
+// minusValueExpr is an unary minus
+type minusValueExpr struct {
+	expR  exprNode
+	token ParserToken
+}
+
+func (ex *minusValueExpr) evaluate(sc *scope) (valueNode, error) {
+
+	right, err := sc.eval(ex.expR)
+	if err != nil {
+		return nil, err
+	}
+	right = sc.settleUnary(right, intValue)
+
+	switch right.isValue() {
+	case unknownValue:
+		return right, nil
+	case intValue:
+		var val int
+		right.value(&val)
+		return &intValueExpr{val: -val}, nil
+	case floatValue:
+		var val float64
+		right.value(&val)
+		return &floatValueExpr{val: -val}, nil
+	case durationValue:
+		return &durationValueExpr{val: -durationOf(right)}, nil
+	}
+
+	return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't evaluate -%s", right.isValue()))
+}
+
+func isNumeric(v valueT) bool {
+	return v == intValue || v == floatValue
+}
+
+// isFloatPromoted reports if both values are numbers and at least one of them is a float,
+// in that case an int operand is promoted to float
+func isFloatPromoted(left, right valueNode) bool {
+	return isNumeric(left.isValue()) && isNumeric(right.isValue()) &&
+		(left.isValue() == floatValue || right.isValue() == floatValue)
+}
+
+// floatOf returns a numeric value as float64
+func floatOf(node valueNode) float64 {
+
+	if node.isValue() == intValue {
+		var val int
+		node.value(&val)
+		return float64(val)
+	}
+
+	var val float64
+	node.value(&val)
+	return val
+}
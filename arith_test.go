@@ -0,0 +1,206 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEvaluateArithmetic_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"1 + 2 == 3", true, nil},
+		{"failed_runs * 2 > max_runs", true, nil},
+		{"failed_runs * 2 > max_runs + 1", false, nil},
+		{"elapsed % 60 == 0", true, nil},
+		{"elapsed / 60 == 2", true, nil},
+		{"2 + 3 * 4 == 14", true, nil},
+		{"(2 + 3) * 4 == 20", true, nil},
+		{"10 - 4 - 3 == 3", true, nil},
+		{"100 / 10 / 5 == 2", true, nil},
+		{"7 % 4 * 2 == 6", true, nil},
+		{"-3 + 5 == 2", true, nil},
+		{"-failed_runs == -5", true, nil},
+		{"- failed_runs < 0", true, nil},
+		{"3 - -2 == 5", true, nil},
+		{"-(2 + 3) == -5", true, nil},
+		{"elapsed - 7 - 3 == 110", true, nil},
+		{"5-3 == 2", true, nil},
+		{"-7 / 2 == -3", true, nil},
+		{"-7 % 2 == -1", true, nil},
+		{"failed_runs * 2 > max_runs && elapsed % 60 == 0", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"failed_runs": 5,
+		"max_runs":    9,
+		"elapsed":     120,
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestEvaluateArithmetic_Negative(t *testing.T) {
+	input := []testCaseExpect{
+		{"elapsed / 0 == 1", false, EvaluateError{}},
+		{"elapsed % (max_runs - 9) == 1", false, EvaluateError{}},
+		{"elapsed + name == 1", false, EvaluateError{}},
+		{"elapsed * true == 1", false, EvaluateError{}},
+		{"-name == 1", false, EvaluateError{}},
+		{"-flag", false, EvaluateError{}},
+		{"elapsed + 1", false, EvaluateError{}},
+	}
+
+	values := map[string]interface{}{
+		"max_runs": 9,
+		"elapsed":  120,
+		"name":     "job",
+		"flag":     true,
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error:", in.expectedError)
+		} else if _, ok := err.(EvaluateError); !ok {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.expectedError)
+		}
+	}
+}
+
+func TestEvaluateFloat_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"1.5 == 1.5", true, nil},
+		{"ratio > 0.5", true, nil},
+		{"ratio < .8", true, nil},
+		{"1e-3 == 0.001", true, nil},
+		{"1E3 == 1000", true, nil},
+		{"2.5e+1 == 25", true, nil},
+		{"1 == 1.0", true, nil},
+		{"count == 3.0", true, nil},
+		{"count < 3.5", true, nil},
+		{"count + 0.5 == 3.5", true, nil},
+		{"count / 2 == 1", true, nil},
+		{"count / 2.0 == 1.5", true, nil},
+		{"ratio * 2 == 1.5", true, nil},
+		{"5.5 % 2 == 1.5", true, nil},
+		{"-ratio == -0.75", true, nil},
+		{"-.5 < 0", true, nil},
+		{"1. == 1", true, nil},
+		{"ratio != 0.75 || count > ratio", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"ratio": 0.75,
+		"count": 3,
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestEvaluateFloat_Negative(t *testing.T) {
+	input := []testCaseExpect{
+		{"ratio / 0 == 1", false, EvaluateError{}},
+		{"ratio / 0.0 == 1", false, EvaluateError{}},
+		{"ratio % 0 == 1", false, EvaluateError{}},
+		{"ratio == '0.75'", false, EvaluateError{}},
+		{"ratio > flag", false, EvaluateError{}},
+		{"ratio", false, EvaluateError{}},
+	}
+
+	values := map[string]interface{}{
+		"ratio": 0.75,
+		"flag":  true,
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error:", in.expectedError)
+		} else if _, ok := err.(EvaluateError); !ok {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.expectedError)
+		}
+	}
+}
+
+func TestEvaluateArithmetic_UnspacedMinus(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"x-1 == 0", ErrAmbiguousName},
+		{"a-b == 3", ErrAmbiguousName},
+		{"x-1.5 < 0", ErrAmbiguousName},
+		{"a-b-c == 0", ErrAmbiguousName},
+		{"elapsed-7 - 3 == 0", ErrAmbiguousName},
+		{"x-y == 0", ErrAmbiguousName},
+		{"x-(1) == 0", ErrAmbiguousName},
+		{"label-01", ErrUndefinedVariable},
+	}
+
+	values := map[string]interface{}{"x": 1, "a": 5, "b": 2, "c": 3, "elapsed-7": 10, "x-y": 0}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+		if err := Validate(in.testCase); !errors.Is(err, in.code) && in.code == ErrAmbiguousName {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+
+	// a name with - used where a number is not expected is a variable, the undefined policy applies to it
+	// and names absent from the expression are not resolved
+	resolver := &countingResolver{values: map[string]interface{}{"a-b": true}}
+	prog, err := Compile("job-run-2 || a-b")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+	if r, err := prog.Evaluate(context.Background(), resolver, WithUndefined(UndefinedUnknown)); err != nil || r != True {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+	if len(resolver.names) != 2 || resolver.names[0] != "job-run-2" || resolver.names[1] != "a-b" {
+		t.Error("unexpected result:", resolver.names)
+	}
+
+	// Check reads a name as a subtraction only if its parts are in the schema
+	if err := Check("x-1 == 0", map[string]Type{"x": TypeInt}); !errors.Is(err, ErrAmbiguousName) {
+		t.Error("unexpected result:", err)
+	}
+	if err := Check("x-1 || x-y", map[string]Type{"x-1": TypeBool, "x": TypeInt, "y": TypeInt}); !errors.Is(err, ErrAmbiguousName) || len(err.(ErrorList)) != 1 {
+		t.Error("unexpected result:", err)
+	}
+}
+
+// countingResolver records names it is asked to resolve
+type countingResolver struct {
+	values map[string]interface{}
+	names  []string
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, name string) (Value, error) {
+
+	r.names = append(r.names, name)
+	v, ok := r.values[name]
+	if !ok {
+		return nil, ErrUndefinedVariable
+	}
+
+	return v, nil
+}
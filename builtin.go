@@ -0,0 +1,123 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// builtins are functions available to all expressions, an environment starts with them
+// and a function registered with the same name replaces a built-in one.
+// Calendar functions of expressions compiled without an environment have no calendars
+var builtins = withLiterals(newBuiltins(stringFuncs, newCalendarSet().funcs(), cronFuncs), cronLiterals)
+
+// defaultEnv is the environment of package level functions like Compile, it has built-in functions
+// and default qualifiers only
+var defaultEnv = &Env{funcs: builtins, qualifiers: newQualifierSet()}
+
+// stringFuncs are built-in string functions, strings are measured and cut in characters
+var stringFuncs = map[string]interface{}{
+	"len":        builtinLen,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"contains":   strings.Contains,
+	"startsWith": strings.HasPrefix,
+	"endsWith":   strings.HasSuffix,
+	"substr":     builtinSubstr,
+	"replace":    builtinReplace,
+	"split":      strings.Split,
+	"join":       strings.Join,
+}
+
+func newBuiltins(sets ...map[string]interface{}) map[string]*function {
+
+	result := map[string]*function{}
+	for _, funcs := range sets {
+		for name, fn := range funcs {
+			f, err := newFunction(name, fn)
+			if err != nil {
+				panic(err)
+			}
+			result[name] = f
+		}
+	}
+
+	return result
+}
+
+// withLiterals sets checks of string literal arguments of the functions
+func withLiterals(funcs map[string]*function, literals map[string][]func(lit string) error) map[string]*function {
+
+	for name, checks := range literals {
+		funcs[name].literals = checks
+	}
+
+	return funcs
+}
+
+// builtinLen returns the number of characters of a string or the number of items of a list
+func builtinLen(v interface{}) (int, error) {
+
+	switch x := v.(type) {
+	case string:
+		return utf8.RuneCountInString(x), nil
+	case []interface{}:
+		return len(x), nil
+	}
+
+	return 0, fmt.Errorf("expected string or list, got %s", kindName(v))
+}
+
+// builtinSubstr returns characters of a string from the start to the end of the string,
+// or at most length characters if the length is given
+func builtinSubstr(s string, start int, length ...int) (string, error) {
+
+	runes := []rune(s)
+	if start < 0 || start > len(runes) {
+		return "", fmt.Errorf("start out of range:%d, length:%d", start, len(runes))
+	}
+	if len(length) > 1 {
+		return "", fmt.Errorf("expects at most 3 arguments, got %d", len(length)+2)
+	}
+
+	end := len(runes)
+	if len(length) == 1 {
+		if length[0] < 0 {
+			return "", fmt.Errorf("negative length:%d", length[0])
+		}
+		if start+length[0] < end {
+			end = start + length[0]
+		}
+	}
+
+	return string(runes[start:end]), nil
+}
+
+func builtinReplace(s, old, with string) string {
+	return strings.ReplaceAll(s, old, with)
+}
+
+// kindName returns the name of a kind of a value passed to an interface{} parameter
+func kindName(v interface{}) string {
+
+	switch v.(type) {
+	case bool:
+		return boolValue.String()
+	case int:
+		return intValue.String()
+	case float64:
+		return floatValue.String()
+	case string:
+		return stringValue.String()
+	case []interface{}:
+		return listValue.String()
+	case time.Time:
+		return timeValue.String()
+	case time.Duration:
+		return durationValue.String()
+	}
+
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,153 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuiltin_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"len('abc') == 3", true, nil},
+		{"len(animal) == 4", true, nil},
+		{"len('') == 0", true, nil},
+		{"len(runs) == 2 && len([]) == 0", true, nil},
+		{"lower(name) == 'job_01'", true, nil},
+		{"upper(name) == 'JOB_01'", true, nil},
+		{"trim(padded) == 'a b'", true, nil},
+		{"contains(name, 'b_0')", true, nil},
+		{"contains(name, 'x')", false, nil},
+		{"startsWith(name, 'Job') && endsWith(name, '01')", true, nil},
+		{"startsWith(name, 'job')", false, nil},
+		{"substr(name, 4) == '01'", true, nil},
+		{"substr(name, 0, 3) == 'Job'", true, nil},
+		{"substr(name, 4, 10) == '01'", true, nil},
+		{"substr(name, 6) == ''", true, nil},
+		{"substr(animal, 1, 2) == middle", true, nil},
+		{"replace(name, '_', '-') == 'Job-01'", true, nil},
+		{"replace('a.a.a', '.', '') == 'aaa'", true, nil},
+		{"split('a,b,c', ',')[1] == 'b'", true, nil},
+		{"len(split('a,b,c', ',')) == 3", true, nil},
+		{"'b' in split('a,b,c', ',')", true, nil},
+		{"join(runs, '+') == 'a+b'", true, nil},
+		{"join(split('a b', ' '), '') == 'ab'", true, nil},
+		{"upper(substr(lower(name), 0, 1)) == 'J'", true, nil},
+		{"len('ż') == 1 && len('żółw') == 4", true, nil},
+		{"lower('ŻÓŁW') == animal && upper(animal) == 'ŻÓŁW'", true, nil},
+		{"substr('żółw', 1, 2) == middle && 'ół' == middle", true, nil},
+		{"contains('zażółć', 'żół') && replace(animal, 'ż', 'z') == 'zółw'", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"name":   "Job_01",
+		"runs":   []string{"a", "b"},
+		"animal": "żółw",
+		"middle": "ół",
+		"padded": "  a b \t",
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestBuiltin_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"len(true) == 1", ErrCallFailed},
+		{"len(retries) == 1", ErrCallFailed},
+		{"lower(1) == '1'", ErrTypeMismatch},
+		{"lower(retries) == '1'", ErrTypeMismatch},
+		{"contains(name)", ErrArgumentCount},
+		{"substr(name) == ''", ErrArgumentCount},
+		{"substr(name, 7) == ''", ErrCallFailed},
+		{"substr(name, -1) == ''", ErrCallFailed},
+		{"substr(name, 0, -1) == ''", ErrCallFailed},
+		{"substr(name, 0, 1, 2) == ''", ErrCallFailed},
+		{"substr(name, '1') == ''", ErrTypeMismatch},
+		{"join([1, 2], ',') == '1,2'", ErrTypeMismatch},
+		{"join(name, ',') == ''", ErrTypeMismatch},
+		{"split(name, '_') == 'Job'", ErrTypeMismatch},
+		{"lowercase(name) == ''", ErrUndefinedFunction},
+	}
+
+	values := map[string]interface{}{
+		"name":    "Job_01",
+		"retries": 1,
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+}
+
+func TestBuiltin_Env(t *testing.T) {
+
+	env := NewEnv()
+	if err := env.Register("upper", func(s string) string { return "X" }); err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+
+	prog, err := env.Compile("upper('a') == 'X' && lower('A') == 'a'")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+	if r, err := prog.Eval(nil); err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	// registering on an environment doesn't change built-in functions
+	if r, err := Eval("upper('a') == 'A'", nil); err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+}
+
+func TestBuiltin_Validate(t *testing.T) {
+
+	if err := Validate("contains(name, 'a') && len(runs) > 1"); err != nil {
+		t.Error("unexpected result:", err)
+	}
+	if err := Test("startsWith(name, 'a') || endsWith(name, 'b')"); err != nil {
+		t.Error("unexpected result:", err)
+	}
+
+	env := NewEnv()
+	env.Register("overdue", func(runs int) bool { return runs > 3 })
+	if err := env.Validate("overdue(runs) && upper(name) == 'A'"); err != nil {
+		t.Error("unexpected result:", err)
+	}
+	if err := Validate("overdue(runs)"); !errors.Is(err, ErrUndefinedFunction) {
+		t.Error("unexpected result:", err)
+	}
+	if err := env.Validate("overdue('x') && overdue()"); err == nil || len(err.(ErrorList)) != 2 {
+		t.Error("unexpected result:", err)
+	}
+	if err := env.Check("overdue(runs) && name", map[string]Type{"runs": TypeInt, "name": TypeString}); !errors.Is(err, ErrTypeMismatch) {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestBuiltin_UnicodePosition(t *testing.T) {
+
+	// positions are counted in characters, offsets in bytes
+	_, err := Eval("'żółw' == animal", map[string]interface{}{})
+	var perr ParserError
+	if !errors.As(err, &perr) || perr.Column != 11 || perr.Offset != 13 || perr.Token != "animal" {
+		t.Error("unexpected result:", err)
+	}
+
+	infos, err := ExtractInfo("lower(nazwa) == 'żółw' && dlugosc > 1")
+	if err != nil || len(infos) != 2 || infos[1].Spans[0] != (Span{Line: 1, Column: 27, Offset: 29, Length: 7}) {
+		t.Error("unexpected result:", infos, "error:", err)
+	}
+}
@@ -0,0 +1,58 @@
+package expr
+
+import "sync"
+
+// maxCompiled limits the number of patterns and cron expressions compiled on evaluation that are kept in the cache
+const maxCompiled = 512
+
+// compiled holds patterns and cron expressions compiled on evaluation, keys are prefixed with their kind
+var compiled = newBoundedCache(maxCompiled)
+
+// boundedCache holds values built from their keys, it is shared by all programs,
+// the cache is cleared when it is full
+type boundedCache struct {
+	mu    sync.Mutex
+	max   int
+	items map[string]interface{}
+}
+
+func newBoundedCache(max int) *boundedCache {
+	return &boundedCache{max: max, items: map[string]interface{}{}}
+}
+
+// get returns a cached value of the key, a missing value is built and cached, errors are not cached.
+// Values are built without holding the lock, so a value built concurrently may be built twice
+func (c *boundedCache) get(key string, build func() (interface{}, error)) (interface{}, error) {
+
+	c.mu.Lock()
+	v, ok := c.items[key]
+	c.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.items) >= c.max {
+		c.items = map[string]interface{}{}
+	}
+	c.items[key] = v
+
+	return v, nil
+}
+
+// has reports if a value of the key is cached
+func (c *boundedCache) has(key string) bool {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.items[key]
+	return ok
+}
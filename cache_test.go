@@ -0,0 +1,39 @@
+package expr
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestBoundedCache(t *testing.T) {
+
+	builds := 0
+	cache := newBoundedCache(2)
+	build := func(key string) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			builds++
+			if key == "" {
+				return nil, errors.New("empty key")
+			}
+			return key + strconv.Itoa(builds), nil
+		}
+	}
+
+	for _, key := range []string{"a", "a", "b", "b"} {
+		cache.get(key, build(key))
+	}
+	if v, err := cache.get("a", build("a")); err != nil || v != "a1" || builds != 2 {
+		t.Error("unexpected result:", v, "error:", err, "builds:", builds)
+	}
+
+	// the cache is cleared when it is full
+	if v, err := cache.get("c", build("c")); err != nil || v != "c3" || cache.has("a") || !cache.has("c") {
+		t.Error("unexpected result:", v, "error:", err)
+	}
+
+	// errors are not cached
+	if _, err := cache.get("", build("")); err == nil || cache.has("") {
+		t.Error("unexpected result:", err)
+	}
+}
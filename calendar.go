@@ -0,0 +1,202 @@
+package expr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calendar decides which days are holidays, days of a calendar are business days unless
+// they are weekends or holidays. IsHoliday gets a date at midnight in the location of the checked time
+type Calendar interface {
+	IsHoliday(date time.Time) bool
+}
+
+// CalendarFunc is an adapter to use an ordinary function as a Calendar
+type CalendarFunc func(date time.Time) bool
+
+func (fn CalendarFunc) IsHoliday(date time.Time) bool {
+	return fn(date)
+}
+
+// calendarSet holds calendars of an environment, calendar functions of the environment use them
+type calendarSet struct {
+	sync.RWMutex
+	named map[string]Calendar
+}
+
+func newCalendarSet() *calendarSet {
+	return &calendarSet{named: map[string]Calendar{}}
+}
+
+// RegisterCalendar makes a calendar available to calendar functions of expressions compiled with the environment,
+// a calendar registered with the same name is replaced, a nil calendar removes the name.
+// Calendars can be registered while programs of the environment are evaluated
+func (e *Env) RegisterCalendar(name string, cal Calendar) {
+
+	e.calendars.Lock()
+	defer e.calendars.Unlock()
+
+	if cal == nil {
+		delete(e.calendars.named, name)
+		return
+	}
+	e.calendars.named[name] = cal
+}
+
+// lookup returns a calendar registered with the name, without a name there are no holidays
+func (s *calendarSet) lookup(name []string) (Calendar, error) {
+
+	if len(name) == 0 {
+		return nil, nil
+	}
+	if len(name) > 1 {
+		return nil, fmt.Errorf("expected a single calendar name, got %d", len(name))
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	cal, ok := s.named[name[0]]
+	if !ok {
+		return nil, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrUndefinedCalendar}, msg: fmt.Sprintf("undefined calendar:%s", name[0])}
+	}
+
+	return cal, nil
+}
+
+// HolidayCalendar is a Calendar with a fixed set of holidays
+type HolidayCalendar map[string]bool
+
+// NewHolidayCalendar returns a calendar with the holidays, only dates of the holidays matter
+func NewHolidayCalendar(holidays ...time.Time) HolidayCalendar {
+
+	cal := HolidayCalendar{}
+	for _, day := range holidays {
+		cal[day.Format(dateLayout)] = true
+	}
+
+	return cal
+}
+
+// ReadHolidayCalendar reads holidays, one date in 2006-01-02 format per line,
+// empty lines and lines starting with # are skipped
+func ReadHolidayCalendar(r io.Reader) (HolidayCalendar, error) {
+
+	cal := HolidayCalendar{}
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		day, err := time.Parse(dateLayout, text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday:%s,line:%d", text, line)
+		}
+		cal[day.Format(dateLayout)] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cal, nil
+}
+
+func (cal HolidayCalendar) IsHoliday(date time.Time) bool {
+	return cal[date.Format(dateLayout)]
+}
+
+// funcs returns built-in calendar functions using calendars of the set, days are computed in the location of a time
+func (s *calendarSet) funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"weekday":           builtinWeekday,
+		"is_weekend":        builtinIsWeekend,
+		"is_business_day":   s.isBusinessDay,
+		"month_end":         builtinMonthEnd,
+		"add_business_days": s.addBusinessDays,
+	}
+}
+
+// builtinWeekday returns a day of the week from 1 for Monday to 7 for Sunday
+func builtinWeekday(t time.Time) int {
+
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+func builtinIsWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// isBusinessDay reports if a day is neither a weekend nor a holiday of the calendar, if it is given
+func (s *calendarSet) isBusinessDay(t time.Time, calendar ...string) (bool, error) {
+
+	cal, err := s.lookup(calendar)
+	if err != nil {
+		return false, err
+	}
+
+	return isBusinessDay(t, cal), nil
+}
+
+// builtinMonthEnd returns the midnight of the last day of the month
+func builtinMonthEnd(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location())
+}
+
+// addBusinessDays moves a time by n business days, forward for a positive n and backward
+// for a negative one, the time of the day is kept. Days are checked one by one, so n is limited to maxBusinessDays
+func (s *calendarSet) addBusinessDays(t time.Time, n int, calendar ...string) (time.Time, error) {
+
+	if n > maxBusinessDays || n < -maxBusinessDays {
+		return time.Time{}, fmt.Errorf("business days out of range:%d, expected at most %d", n, maxBusinessDays)
+	}
+
+	cal, err := s.lookup(calendar)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+
+	for idle := 0; n > 0; {
+		if idle == maxIdleDays {
+			return time.Time{}, fmt.Errorf("no business day within %d days", maxIdleDays)
+		}
+		t, idle = t.AddDate(0, 0, step), idle+1
+		if isBusinessDay(t, cal) {
+			n, idle = n-1, 0
+		}
+	}
+
+	return t, nil
+}
+
+// maxIdleDays limits the number of consecutive days without a business day add_business_days looks through
+const maxIdleDays = 366
+
+// maxBusinessDays limits the number of business days add_business_days moves a time by, it is about 40 years
+const maxBusinessDays = 10000
+
+func isBusinessDay(t time.Time, cal Calendar) bool {
+
+	if builtinIsWeekend(t) {
+		return false
+	}
+	if cal == nil {
+		return true
+	}
+
+	return !cal.IsHoliday(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()))
+}
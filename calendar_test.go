@@ -0,0 +1,138 @@
+package expr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Positive(t *testing.T) {
+
+	env := NewEnv()
+	env.RegisterCalendar("PL", NewHolidayCalendar(time.Date(2026, 11, 11, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)))
+	env.RegisterCalendar("NONE", CalendarFunc(func(date time.Time) bool { return false }))
+
+	input := []testCaseExpect{
+		{"weekday(d'2026-10-19') == 1 && weekday(d'2026-10-17') == 6 && weekday(d'2026-10-18') == 7", true, nil},
+		{"is_weekend(d'2026-10-17') && is_weekend(d'2026-10-18') && !is_weekend(d'2026-10-19')", true, nil},
+		{"is_business_day(d'2026-11-11') && !is_business_day(d'2026-11-11', 'PL')", true, nil},
+		{"!is_business_day(d'2026-10-17', 'NONE') && is_business_day(d'2026-11-12', 'PL')", true, nil},
+		{"is_business_day(run_date, 'PL')", false, nil},
+		{"month_end(d'2026-02-10') == d'2026-02-28' && month_end(d'2028-02-01') == d'2028-02-29'", true, nil},
+		{"month_end(d'2026-12-31') == d'2026-12-31'", true, nil},
+		{"month_end(local) == t'2026-10-31T00:00:00+02:00'", true, nil},
+		{"add_business_days(d'2026-10-16', 1) == d'2026-10-19'", true, nil},
+		{"add_business_days(d'2026-10-19', -1) == d'2026-10-16'", true, nil},
+		{"add_business_days(d'2026-10-17', 0) == d'2026-10-17'", true, nil},
+		{"add_business_days(d'2026-11-10', 1, 'PL') == d'2026-11-12'", true, nil},
+		{"add_business_days(d'2026-12-23', 2, 'PL') == d'2026-12-28'", true, nil},
+		{"add_business_days(t'2026-10-16T08:00:00Z', 5) == t'2026-10-23T08:00:00Z'", true, nil},
+		{"add_business_days(d'2026-10-19', 10000) == d'2065-02-16' && add_business_days(d'2065-02-16', -10000) == d'2026-10-19'", true, nil},
+		{"run_date == month_end(run_date) || add_business_days(run_date, 1, 'PL') > run_date", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"run_date": time.Date(2026, 12, 25, 8, 0, 0, 0, time.UTC),
+		"local":    time.Date(2026, 10, 17, 23, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	}
+
+	for i, in := range input {
+		prog, err := env.Compile(in.testCase)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+			continue
+		}
+		r, err := prog.Eval(values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestCalendar_Negative(t *testing.T) {
+
+	env := NewEnv()
+	env.RegisterCalendar("ALL", CalendarFunc(func(date time.Time) bool { return true }))
+
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"is_business_day(d'2026-10-19', 'MISSING')", ErrUndefinedCalendar},
+		{"add_business_days(d'2026-10-19', 1, 'MISSING') == d'2026-10-20'", ErrUndefinedCalendar},
+		{"add_business_days(d'2026-10-19', 1, 'ALL') == d'2026-10-20'", ErrCallFailed},
+		{"is_business_day(d'2026-10-19', 'ALL', 'ALL')", ErrCallFailed},
+		{"weekday('2026-10-19') == 1", ErrTypeMismatch},
+		{"is_weekend(run_date)", ErrTypeMismatch},
+		{"add_business_days(d'2026-10-19', 1.5) == d'2026-10-20'", ErrTypeMismatch},
+		{"month_end()", ErrArgumentCount},
+		{"add_business_days(d'2026-01-01', 20000000) > d'2026-01-01'", ErrCallFailed},
+		{"add_business_days(d'2026-01-01', -10001) < d'2026-01-01'", ErrCallFailed},
+	}
+
+	values := map[string]interface{}{"run_date": "2026-10-19"}
+
+	for i, in := range input {
+		prog, err := env.Compile(in.testCase)
+		if err == nil {
+			_, err = prog.Eval(values)
+		}
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+}
+
+func TestCalendar_Env(t *testing.T) {
+
+	holiday := time.Date(2026, 11, 11, 0, 0, 0, 0, time.UTC)
+	first, second := NewEnv(), NewEnv()
+	first.RegisterCalendar("PL", NewHolidayCalendar(holiday))
+	second.RegisterCalendar("PL", NewHolidayCalendar())
+
+	// calendars of one environment don't change calendars of another one
+	expected := map[*Env]bool{first: false, second: true}
+	for env, want := range expected {
+		prog, err := env.Compile("is_business_day(d'2026-11-11', 'PL')")
+		if err != nil {
+			t.Fatal("unexpected result:", err)
+		}
+		if r, err := prog.Eval(nil); err != nil || r != want {
+			t.Error("unexpected result:", r, "error:", err, "expected:", want)
+		}
+	}
+
+	// a calendar can be replaced and removed after the program is compiled
+	prog, _ := second.Compile("is_business_day(d'2026-11-11', 'PL')")
+	second.RegisterCalendar("PL", NewHolidayCalendar(holiday))
+	if r, err := prog.Eval(nil); err != nil || r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+	second.RegisterCalendar("PL", nil)
+	if _, err := prog.Eval(nil); !errors.Is(err, ErrUndefinedCalendar) {
+		t.Error("unexpected result:", err)
+	}
+
+	if _, err := Eval("is_business_day(d'2026-11-11', 'PL')", nil); !errors.Is(err, ErrUndefinedCalendar) {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestCalendar_Read(t *testing.T) {
+
+	cal, err := ReadHolidayCalendar(strings.NewReader("# holidays\n2026-11-11\n\n  2026-12-25  \n"))
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+	if len(cal) != 2 || !cal.IsHoliday(time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)) || cal.IsHoliday(time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC)) {
+		t.Error("unexpected result:", cal)
+	}
+
+	if _, err := ReadHolidayCalendar(strings.NewReader("2026-11-11\n11.11.2026\n")); err == nil || !strings.Contains(err.Error(), "line:2") {
+		t.Error("unexpected result:", err)
+	}
+}
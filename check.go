@@ -0,0 +1,344 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Type is a type of a value in an expression
+type Type uint8
+
+const (
+	TypeBool   = Type(boolValue)
+	TypeInt    = Type(intValue)
+	TypeString = Type(stringValue)
+	TypeFloat  = Type(floatValue)
+	// TypeList is a list of any values, types of its items are not checked
+	TypeList     = Type(listValue)
+	TypeTime     = Type(timeValue)
+	TypeDuration = Type(durationValue)
+)
+
+func (t Type) String() string {
+	return valueT(t).String()
+}
+
+// invalidValue is a type of a node that failed type checking, operators with an invalid
+// operand don't report errors, so a single mistake is reported only once
+const invalidValue valueT = 255
+
+type typeChecker struct {
+	schema map[string]Type
+	errs   ErrorList
+	// free is set when a program is checked at compile time, identifiers are free symbols
+	// of unknown types and only errors of function calls are reported
+	free bool
+	// usage is set when types of identifiers are inferred, it holds the identifiers in order
+	// of checking and the types they must have given how they are used
+	usage *usage
+	// ambiguous holds identifiers reported as unspaced subtractions in the free mode
+	ambiguous map[*identExpr]bool
+}
+
+type usage struct {
+	idents []*identExpr
+	kinds  map[*identExpr]valueT
+}
+
+// expect records the type an operand must have if it is an identifier, the first expected type is kept.
+// In the free mode a number expected from a name like x-1 is reported as an unspaced subtraction
+func (tc *typeChecker) expect(ex exprNode, t valueT) {
+
+	ident, ok := ex.(*identExpr)
+	if !ok || t == invalidValue {
+		return
+	}
+	if tc.free && isNumeric(t) && !tc.ambiguous[ident] && isSubtraction(ident.name, plainName.MatchString) {
+		if tc.ambiguous == nil {
+			tc.ambiguous = map[*identExpr]bool{}
+		}
+		tc.ambiguous[ident] = true
+		tc.reportCall(ErrAmbiguousName, ident.token, "ambiguous name:%s, put spaces around - to subtract", ident.name)
+	}
+	if tc.usage == nil {
+		return
+	}
+	if _, ok := tc.usage.kinds[ident]; !ok {
+		tc.usage.kinds[ident] = t
+	}
+}
+
+// expectSame records the type of the other operand for an operand of an unknown type
+func (tc *typeChecker) expectSame(exprL, exprR exprNode, left, right valueT) {
+
+	if left == invalidValue {
+		tc.expect(exprL, right)
+	}
+	if right == invalidValue {
+		tc.expect(exprR, left)
+	}
+}
+
+func (tc *typeChecker) report(code ErrorCode, token ParserToken, format string, args ...interface{}) {
+	if tc.free {
+		return
+	}
+	tc.errs = append(tc.errs, newTypeErrorAt(code, token, fmt.Sprintf(format, args...)))
+}
+
+// reportCall reports an error of a function call, it is reported in the free mode too
+func (tc *typeChecker) reportCall(code ErrorCode, token ParserToken, format string, args ...interface{}) {
+	tc.errs = append(tc.errs, newTypeErrorAt(code, token, fmt.Sprintf(format, args...)))
+}
+
+// Check compiles an expression and verifies types of its operands against the schema,
+// all type errors are returned as an ErrorList
+func Check(expr string, schema map[string]Type) error {
+
+	prog, err := Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	return prog.Check(schema)
+}
+
+// Check verifies types of the program operands against the schema without evaluating it
+func (p *Program) Check(schema map[string]Type) error {
+
+	tc := &typeChecker{schema: schema}
+
+	if t := p.root.check(tc); t != boolValue && t != invalidValue {
+		tc.errs = append(tc.errs, TypeError{ErrorDetail: ErrorDetail{Code: ErrTypeMismatch}, msg: fmt.Sprintf("expression does not evaluate to bool, it is %s", t)})
+	}
+
+	if len(tc.errs) == 0 {
+		return nil
+	}
+
+	return tc.errs
+}
+
+func (ex *boolValueExpr) check(tc *typeChecker) valueT   { return boolValue }
+func (ex *intValueExpr) check(tc *typeChecker) valueT    { return intValue }
+func (ex *floatValueExpr) check(tc *typeChecker) valueT  { return floatValue }
+func (ex *stringValueExpr) check(tc *typeChecker) valueT { return stringValue }
+
+func (ex *badExpr) check(tc *typeChecker) valueT { return invalidValue }
+
+func (ex *listValueExpr) check(tc *typeChecker) valueT { return listValue }
+
+func (ex *listExpr) check(tc *typeChecker) valueT {
+
+	for _, item := range ex.items {
+		item.check(tc)
+	}
+
+	return listValue
+}
+
+// check of an index returns invalidValue for a valid index, types of items are not tracked,
+// so operators using an item don't report errors
+func (ex *indexExpr) check(tc *typeChecker) valueT {
+
+	list, index := ex.expr.check(tc), ex.index.check(tc)
+	tc.expect(ex.expr, listValue)
+	tc.expect(ex.index, intValue)
+	if list != listValue && list != invalidValue {
+		tc.report(ErrTypeMismatch, ex.token, "can't index %s", list)
+	}
+	if index != intValue && index != invalidValue {
+		tc.report(ErrTypeMismatch, ex.token, "can't index list with %s, index must be int", index)
+	}
+
+	return invalidValue
+}
+
+// plainName is a name of a variable without dots and hyphens
+var plainName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// isSubtraction reports if a name is a defined variable, a - and a number, a defined variable or another such name,
+// like x-1 or a-b. Names like label-01 are variables, but an unspaced subtraction is rejected rather than read as a name
+func isSubtraction(name string, defined func(name string) bool) bool {
+
+	for i := 1; i < len(name)-1; i++ {
+		if name[i] != '-' || strings.Contains(name[:i], ".") || !defined(name[:i]) {
+			continue
+		}
+		right := name[i+1:]
+		if _, err := strconv.ParseFloat(right, 64); err == nil || defined(right) || isSubtraction(right, defined) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ex *identExpr) check(tc *typeChecker) valueT {
+
+	if tc.usage != nil {
+		tc.usage.idents = append(tc.usage.idents, ex)
+	}
+	if tc.free {
+		return invalidValue
+	}
+
+	t, ok := tc.schema[ex.name]
+	if !ok && isSubtraction(ex.name, func(name string) bool { _, ok := tc.schema[name]; return ok }) {
+		tc.report(ErrAmbiguousName, ex.token, "ambiguous name:%s, put spaces around - to subtract", ex.name)
+		return invalidValue
+	}
+	if !ok {
+		tc.report(ErrUndefinedVariable, ex.token, "undefined variable:%s", ex.name)
+		return invalidValue
+	}
+
+	return valueT(t)
+}
+
+func (ex *negValueExpr) check(tc *typeChecker) valueT {
+
+	if t := ex.expR.check(tc); t != boolValue && t != invalidValue {
+		tc.report(ErrTypeMismatch, ex.token, "can't apply %s to %s", ex.token.value, t)
+	}
+	tc.expect(ex.expR, boolValue)
+
+	return boolValue
+}
+
+func (ex *minusValueExpr) check(tc *typeChecker) valueT {
+
+	t := ex.expR.check(tc)
+	if isNumeric(t) || t == durationValue || t == invalidValue {
+		return t
+	}
+
+	tc.report(ErrTypeMismatch, ex.token, "can't apply %s to %s", ex.token.value, t)
+	return invalidValue
+}
+
+func (ex *andOperExpr) check(tc *typeChecker) valueT {
+	return checkLogical(tc, ex.token, ex.exprL, ex.exprR)
+}
+
+func (ex *orOperExpr) check(tc *typeChecker) valueT {
+	return checkLogical(tc, ex.token, ex.exprL, ex.exprR)
+}
+
+func (ex *compareOperExpr) check(tc *typeChecker) valueT {
+	return checkEquality(tc, ex.token, ex.exprL, ex.exprR)
+}
+
+func (ex *notOperExpr) check(tc *typeChecker) valueT {
+	return checkEquality(tc, ex.token, ex.exprL, ex.exprR)
+}
+
+// check of in verifies items of a list literal like operands of ==
+func (ex *inOperExpr) check(tc *typeChecker) valueT {
+
+	left := ex.exprL.check(tc)
+
+	list, ok := ex.exprR.(*listExpr)
+	if !ok {
+		if right := ex.exprR.check(tc); right != listValue && right != invalidValue && left != invalidValue {
+			tc.report(ErrTypeMismatch, ex.token, "can't apply %s to %s and %s", ex.token.value, left, right)
+		}
+		tc.expect(ex.exprR, listValue)
+		return boolValue
+	}
+
+	for i, item := range list.items {
+		right := item.check(tc)
+		tc.expectSame(ex.exprL, item, left, right)
+		if left == invalidValue || right == invalidValue {
+			continue
+		}
+		if left != right && !(isNumeric(left) && isNumeric(right)) {
+			tc.report(ErrTypeMismatch, ex.token, "can't compare %s with %s, item:%d", left, right, i)
+		}
+	}
+
+	return boolValue
+}
+
+func (ex *orderOperExpr) check(tc *typeChecker) valueT {
+
+	left, right := ex.exprL.check(tc), ex.exprR.check(tc)
+	tc.expectSame(ex.exprL, ex.exprR, left, right)
+	if left == invalidValue || right == invalidValue {
+		return boolValue
+	}
+
+	if !(isNumeric(left) && isNumeric(right)) && !(left == right && (left == stringValue || isTemporal(left))) {
+		tc.report(ErrTypeMismatch, ex.token, "can't compare %s with %s", left, right)
+	}
+
+	return boolValue
+}
+
+func (ex *arithOperExpr) check(tc *typeChecker) valueT {
+
+	left, right := ex.exprL.check(tc), ex.exprR.check(tc)
+	if left == invalidValue {
+		tc.expect(ex.exprL, arithOperandKind(ex.oper, right, false))
+	}
+	if right == invalidValue {
+		tc.expect(ex.exprR, arithOperandKind(ex.oper, left, true))
+	}
+	if left == invalidValue || right == invalidValue {
+		return invalidValue
+	}
+
+	if isTemporal(left) || isTemporal(right) {
+		kind, ok := timeArithKind(ex.oper, left, right)
+		if !ok {
+			tc.report(ErrTypeMismatch, ex.token, "can't apply %s to %s and %s", ex.token.value, left, right)
+			return invalidValue
+		}
+		return kind
+	}
+
+	if !isNumeric(left) || !isNumeric(right) {
+		tc.report(ErrTypeMismatch, ex.token, "can't apply %s to %s and %s", ex.token.value, left, right)
+		return invalidValue
+	}
+
+	if left == floatValue || right == floatValue {
+		return floatValue
+	}
+
+	return intValue
+}
+
+func checkLogical(tc *typeChecker, token ParserToken, exprL, exprR exprNode) valueT {
+
+	left, right := exprL.check(tc), exprR.check(tc)
+	tc.expect(exprL, boolValue)
+	tc.expect(exprR, boolValue)
+	if left == invalidValue || right == invalidValue {
+		return boolValue
+	}
+
+	if left != boolValue || right != boolValue {
+		tc.report(ErrTypeMismatch, token, "can't apply %s to %s and %s", token.value, left, right)
+	}
+
+	return boolValue
+}
+
+func checkEquality(tc *typeChecker, token ParserToken, exprL, exprR exprNode) valueT {
+
+	left, right := exprL.check(tc), exprR.check(tc)
+	tc.expectSame(exprL, exprR, left, right)
+	if left == invalidValue || right == invalidValue {
+		return boolValue
+	}
+
+	if left != right && !(isNumeric(left) && isNumeric(right)) {
+		tc.report(ErrTypeMismatch, token, "can't compare %s with %s", left, right)
+	}
+
+	return boolValue
+}
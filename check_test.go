@@ -0,0 +1,111 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+var testSchema = map[string]Type{
+	"label_01": TypeBool,
+	"label_02": TypeBool,
+	"label_03": TypeString,
+	"retries":  TypeInt,
+	"ratio":    TypeFloat,
+	"runs":     TypeList,
+}
+
+func TestCheck_Positive(t *testing.T) {
+	input := []string{
+		"label_01",
+		"label_01 && !label_02 || label_03 == 'x'",
+		"retries > 3 && ratio <= 0.5",
+		"retries * 2 + 1 == ratio",
+		"-retries < -ratio",
+		"label_03 >= '2026-01-01'",
+		"(label_01 == label_02) != false",
+		"label_03 in ['OK', 'ENDED'] && retries not in [1, 2.5]",
+		"runs[retries] > 1 && runs[0] && label_03 in runs",
+		"[1, 2][0] == 1",
+	}
+
+	for i, in := range input {
+		if err := Check(in, testSchema); err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+	}
+}
+
+func TestCheck_Negative(t *testing.T) {
+	input := []struct {
+		expr   string
+		errors int
+	}{
+		{"13 && 15", 1},
+		{"'x' && label_03", 1},
+		{"label_01 && retries", 1},
+		{"!retries", 1},
+		{"-label_01 == 1", 1},
+		{"label_03 > 1", 1},
+		{"label_01 < label_02", 1},
+		{"label_03 + 1 == 2", 1},
+		{"retries == 'abc'", 1},
+		{"retries", 1},
+		{"missing && label_01", 1},
+		{"(label_03 + 1 == 2) && !retries || 13 && label_01", 3},
+		{"missing + 1 > 2 && other", 2},
+		{"label_03 in ['OK', 1, label_01]", 2},
+		{"label_03 in label_03", 1},
+		{"retries[0] == 1", 1},
+		{"runs['a'] == 1", 1},
+		{"runs", 1},
+		{"[missing, 'x'][0] == 1", 1},
+	}
+
+	for i, in := range input {
+		err := Check(in.expr, testSchema)
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error")
+			continue
+		}
+		list, ok := err.(ErrorList)
+		if !ok {
+			t.Error("unexpected result:", i, "error:", err)
+			continue
+		}
+		if len(list) != in.errors {
+			t.Error("unexpected result:", i, "errors:", len(list), "expected:", in.errors, list)
+		}
+		for _, e := range list {
+			if _, ok := e.(TypeError); !ok {
+				t.Error("unexpected result:", i, "error:", e)
+			}
+		}
+	}
+}
+
+func TestCheck_Position(t *testing.T) {
+
+	err := Check("label_01 &&\n retries", testSchema)
+	if err == nil {
+		t.Fatal("unexpected result, expected error")
+	}
+	if !strings.Contains(err.Error(), "line:1,pos:9") {
+		t.Error("unexpected result:", err)
+	}
+
+	err = Check("label_01 &&\n  !retries", testSchema)
+	if err == nil {
+		t.Fatal("unexpected result, expected error")
+	}
+	if !strings.Contains(err.Error(), "line:2") {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestCheck_SyntaxError(t *testing.T) {
+
+	err := Check("label_01 &&", testSchema)
+	if _, ok := err.(ParserError); !ok {
+		t.Error("unexpected result:", err)
+	}
+}
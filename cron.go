@@ -0,0 +1,244 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronFuncs are built-in time window predicates
+var cronFuncs = map[string]interface{}{
+	"cron":    builtinCron,
+	"between": builtinBetween,
+}
+
+// cronLiterals check string literal arguments of cron functions when an expression is compiled
+var cronLiterals = map[string][]func(lit string) error{
+	"cron":    {checkCron},
+	"between": {nil, checkClock, checkClock},
+}
+
+func checkCron(spec string) error {
+	_, err := cachedCron(spec)
+	return err
+}
+
+func checkClock(clock string) error {
+	_, err := parseClock(clock)
+	return err
+}
+
+// builtinCron reports if a time matches a cron expression, the time is matched with a minute
+// precision in its own location
+func builtinCron(spec string, t time.Time) (bool, error) {
+
+	schedule, err := cachedCron(spec)
+	if err != nil {
+		return false, err
+	}
+
+	return schedule.match(t), nil
+}
+
+// builtinBetween reports if the time of the day is in the window from, inclusive, to, exclusive.
+// Times of the day are 15:04 or 15:04:05, a window with from later than to wraps around midnight
+func builtinBetween(t time.Time, from, to string) (bool, error) {
+
+	start, err := parseClock(from)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return false, err
+	}
+
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if start <= end {
+		return clock >= start && clock < end, nil
+	}
+
+	return clock >= start || clock < end, nil
+}
+
+// parseClock returns a time of the day as a duration since midnight
+func parseClock(clock string) (time.Duration, error) {
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+
+	return 0, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrInvalidTime}, msg: fmt.Sprintf("unexpected value:%s, expected time of the day", clock)}
+}
+
+// cronSchedule is a parsed cron expression, every field is a set of allowed values
+type cronSchedule struct {
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var dayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// parseCron parses a cron expression of five fields: minute, hour, day of month, month and day of week.
+// A field is a list of values, ranges or *, each of them with an optional step, months and days of week
+// can be given by names, Sunday is 0 or 7. Macros like @daily are accepted in place of the fields
+func parseCron(spec string) (*cronSchedule, error) {
+
+	expr := strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, cronError(spec, fmt.Sprintf("expected 5 fields, got %d", len(fields)))
+	}
+
+	s := &cronSchedule{domStar: strings.HasPrefix(fields[2], "*"), dowStar: strings.HasPrefix(fields[4], "*")}
+
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, cronError(spec, "minute:"+err.Error())
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, cronError(spec, "hour:"+err.Error())
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, cronError(spec, "day of month:"+err.Error())
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, cronError(spec, "month:"+err.Error())
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, cronError(spec, "day of week:"+err.Error())
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+func cronError(spec, reason string) error {
+	return EvaluateError{ErrorDetail: ErrorDetail{Code: ErrInvalidCron}, msg: fmt.Sprintf("invalid cron expression:%s,%s", spec, reason)}
+}
+
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+
+		span, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step:%s", part[i+1:])
+			}
+			span, step = part[:i], n
+		}
+
+		var lo, hi int
+		switch {
+		case span == "*":
+			lo, hi = min, max
+		case strings.Contains(span, "-"):
+			bounds := strings.SplitN(span, "-", 2)
+			var err error
+			if lo, err = cronValue(bounds[0], names); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(bounds[1], names); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if lo, err = cronValue(span, names); err != nil {
+				return 0, err
+			}
+			// a single value with a step starts a range to the end
+			hi = lo
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("out of range:%s, expected %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func cronValue(value string, names map[string]int) (int, error) {
+
+	if n, ok := names[strings.ToUpper(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value:%s", value)
+	}
+
+	return n, nil
+}
+
+// match reports if a time matches the schedule, if both day of month and day of week are restricted,
+// a day matching any of them matches
+func (s *cronSchedule) match(t time.Time) bool {
+
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+// cachedCron returns a parsed cron expression from the cache
+func cachedCron(spec string) (*cronSchedule, error) {
+
+	schedule, err := compiled.get("cron:"+spec, func() (interface{}, error) {
+		return parseCron(spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule.(*cronSchedule), nil
+}
@@ -0,0 +1,134 @@
+package expr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCron_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"cron('0 9-17 * * 1-5', monday_morning)", true, nil},
+		{"cron('0 9-17 * * 1-5', saturday)", false, nil},
+		{"cron('0 9-17 * * 1-5', monday_evening)", false, nil},
+		{"cron('* * * * *', saturday) && cron('@hourly', saturday)", true, nil},
+		{"cron('*/15 * * * *', monday_morning) && !cron('*/7 * * * 1', t'2026-10-19T09:05:00Z')", true, nil},
+		{"cron('30 9,10 * * MON', t'2026-10-19T10:30:00Z')", true, nil},
+		{"cron('0 0 1 jan-mar *', d'2026-02-01') && !cron('0 0 1 APR-DEC *', d'2026-02-01')", true, nil},
+		{"cron('0 0 * * 7', d'2026-10-18') && cron('0 0 * * 0', d'2026-10-18') && cron('0 0 * * SUN', d'2026-10-18')", true, nil},
+		{"cron('0 0 13 * FRI', d'2026-10-13') && cron('0 0 13 * FRI', d'2026-10-16')", true, nil},
+		{"!cron('0 0 13 * FRI', d'2026-10-14')", true, nil},
+		{"cron('0 0 */2 * *', d'2026-10-17') && !cron('0 0 */2 * *', d'2026-10-18')", true, nil},
+		{"cron('5/20 * * * *', t'2026-10-19T09:45:00Z') && !cron('5/20 * * * *', t'2026-10-19T09:40:00Z')", true, nil},
+		{"cron('@daily', d'2026-10-19') && cron('@weekly', d'2026-10-18') && cron('@monthly', d'2026-10-01')", true, nil},
+		{"cron('0 9 * * *', local)", true, nil},
+		{"cron(schedule, monday_morning) && label_01", true, nil},
+		{"between(monday_morning, '09:00', '17:00') && !between(monday_evening, '09:00', '17:00')", true, nil},
+		{"between(t'2026-10-19T17:00:00Z', '09:00', '17:00')", false, nil},
+		{"between(t'2026-10-19T09:00:00Z', '09:00', '17:00:00')", true, nil},
+		{"between(t'2026-10-19T23:30:00Z', '22:00', '06:00') && between(t'2026-10-19T05:59:59Z', '22:00', '06:00')", true, nil},
+		{"between(t'2026-10-19T12:00:00Z', '22:00', '06:00')", false, nil},
+		{"between(local, '09:00', '09:01')", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"monday_morning": time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		"monday_evening": time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC),
+		"saturday":       time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC),
+		"local":          time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC).In(time.FixedZone("CEST", 2*60*60)),
+		"schedule":       "0 9 * * 1",
+		"label_01":       true,
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestCron_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"cron('0 9-17 * *', now)", ErrInvalidCron},
+		{"cron('0 9-17 * * * *', now)", ErrInvalidCron},
+		{"cron('60 * * * *', now)", ErrInvalidCron},
+		{"cron('* 24 * * *', now)", ErrInvalidCron},
+		{"cron('* * 0 * *', now)", ErrInvalidCron},
+		{"cron('* * * 13 *', now)", ErrInvalidCron},
+		{"cron('* * * * 8', now)", ErrInvalidCron},
+		{"cron('17-9 * * * *', now)", ErrInvalidCron},
+		{"cron('*/0 * * * *', now)", ErrInvalidCron},
+		{"cron('a * * * *', now)", ErrInvalidCron},
+		{"cron('* * * FOO *', now)", ErrInvalidCron},
+		{"cron('@never', now)", ErrInvalidCron},
+		{"cron('* * * * *', '2026-10-19')", ErrTypeMismatch},
+		{"cron(now, now)", ErrTypeMismatch},
+		{"between(now, '9', '17:00')", ErrInvalidTime},
+		{"between(now, '09:00', '25:00')", ErrInvalidTime},
+		{"between(now, '09:00')", ErrArgumentCount},
+	}
+
+	values := map[string]interface{}{"now": time.Now()}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+}
+
+func TestCron_CompileTime(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+		message  string
+	}{
+		{"cron('61 * * * *', t)", ErrInvalidCron, "invalid cron expression:61 * * * *,minute:out of range:61, expected 0-59,line:1,pos:5"},
+		{"label_01 &&\n cron('@never', t)", ErrInvalidCron, "line:2,pos:6"},
+		{"between(t, '09:00', '25:00')", ErrInvalidTime, "unexpected value:25:00, expected time of the day,line:1,pos:20"},
+		{"between(t, ('9'), '17:00')", ErrInvalidTime, "line:1,pos:0"},
+	}
+
+	for i, in := range input {
+		err := Test(in.testCase)
+		var perr ParserError
+		if !errors.Is(err, in.code) || !errors.As(err, &perr) || !strings.HasSuffix(err.Error(), in.message) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.message)
+		}
+	}
+
+	// specs that are not literals are checked on evaluation
+	prog, err := Compile("cron(spec, t) || between(t, from, '17:00')")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+	if _, err := prog.Eval(map[string]interface{}{"spec": "61 * * * *", "t": time.Now(), "from": "9"}); !errors.Is(err, ErrInvalidCron) {
+		t.Error("unexpected result:", err)
+	}
+
+	// a function registered in place of a built-in one doesn't check literals
+	env := NewEnv()
+	env.Register("cron", func(spec string, t time.Time) bool { return spec == "61 * * * *" })
+	if _, err := env.Compile("cron('61 * * * *', t)"); err != nil {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestCron_Cached(t *testing.T) {
+
+	prog, _ := Compile("cron(spec, now)")
+	prog.Eval(map[string]interface{}{"spec": "1 2 3 4 5", "now": time.Now()})
+
+	if !compiled.has("cron:1 2 3 4 5") {
+		t.Error("unexpected result, schedule is not cached")
+	}
+}
@@ -0,0 +1,137 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var errorHints = map[ErrorCode]string{
+	ErrEmptyExpression:    "an expression needs at least one operand",
+	ErrUnexpectedChar:     "remove the character or put it inside a string literal",
+	ErrUnterminatedString: "close the string with a single quote in the same line",
+	ErrUnrecognizedToken:  "valid operators are: ! && || == != < <= > >= =~ !~ + - * / % in, not in",
+	ErrInvalidNumber:      "the number is malformed or out of range",
+	ErrUnexpectedToken:    "an operator is probably missing before this token",
+	ErrUnexpectedEnd:      "the expression is incomplete, an operand is missing",
+	ErrUnbalancedParen:    "every '(' needs a matching ')' and every '[' a matching ']'",
+	ErrUndefinedVariable:  "the variable has no value, check its spelling",
+	ErrUnsupportedType:    "variables must be bool, numbers, strings, times, durations or slices of them",
+	ErrOutOfRange:         "the value doesn't fit in int",
+	ErrTypeMismatch:       "operands of this operator have incompatible types",
+	ErrDivisionByZero:     "the right operand of the division is zero",
+	ErrResolveFailed:      "the value of the variable couldn't be fetched",
+	ErrCanceled:           "the context of the evaluation was canceled or its deadline passed",
+	ErrStepLimit:          "the expression is too complex for the configured step limit",
+	ErrUnknownResult:      "use Evaluate to get a three-valued result",
+	ErrUndefinedField:     "the variable has no such field, check its spelling",
+	ErrIndexOutOfRange:    "the index must be at least 0 and less than the length of the list",
+	ErrUndefinedFunction:  "the function is not registered in the environment the expression is compiled with",
+	ErrArgumentCount:      "check the number of arguments the function takes",
+	ErrCallFailed:         "the function returned an error",
+	ErrInvalidFunction:    "the function can't be called from expressions",
+	ErrInvalidPattern:     "the pattern must be a regular expression in the Go regexp syntax",
+	ErrInvalidTime:        "dates are d'2006-01-02', timestamps t'2006-01-02T15:04:05Z', durations 90m or 1h30m",
+	ErrUndefinedCalendar:  "the calendar must be registered with RegisterCalendar of the Env the expression is compiled with",
+	ErrInvalidCron:        "a cron expression has 5 fields: minute hour day-of-month month day-of-week",
+	ErrInvalidQualifier:   "a qualifier is a single name of letters, digits and underscores registered with RegisterQualifier of the Env",
+	ErrEmptyMember:        "parts of a dotted name can't be empty, remove the extra dot",
+	ErrAmbiguousName:      "a - inside a name is a part of the name, put spaces around - to subtract",
+}
+
+// FormatError renders an error returned for the source as a compiler like diagnostic,
+// the offending line is printed with the token underlined:
+//
+//	2:4: undefined variable:label_03
+//	 && label_03
+//	    ^~~~~~~~
+//	hint: the variable has no value, check its spelling
+//
+// errors of an ErrorList are rendered one after another, other errors are returned as they are
+func FormatError(source string, err error) string {
+
+	if err == nil {
+		return ""
+	}
+
+	var list ErrorList
+	if errors.As(err, &list) {
+		diags := make([]string, len(list))
+		for i, e := range list {
+			diags[i] = FormatError(source, e)
+		}
+		return strings.Join(diags, "\n")
+	}
+
+	detail, ok := detailOf(err)
+	if !ok {
+		return err.Error() + "\n"
+	}
+
+	reason := detail.reason
+	if reason == "" {
+		reason = err.Error()
+	}
+
+	sb := strings.Builder{}
+	lines := strings.Split(source, "\n")
+
+	if detail.Line > 0 && detail.Line <= len(lines) {
+		line := strings.TrimRight(lines[detail.Line-1], "\r")
+		fmt.Fprintf(&sb, "%d:%d: %s\n", detail.Line, detail.Column, reason)
+		fmt.Fprintf(&sb, "%s\n%s\n", line, underline(line, detail.Column, utf8.RuneCountInString(detail.Token)))
+	} else {
+		fmt.Fprintf(&sb, "%s\n", reason)
+	}
+
+	if hint, ok := errorHints[detail.Code]; ok {
+		fmt.Fprintf(&sb, "hint: %s\n", hint)
+	}
+
+	return sb.String()
+}
+
+// underline returns a caret under the column followed by tildes up to the length of the token,
+// tabs of the line are kept so the caret stays aligned
+func underline(line string, column int, length int) string {
+
+	chars := []rune(line)
+	sb := strings.Builder{}
+	for i := 0; i < column-1; i++ {
+		if i < len(chars) && chars[i] == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+
+	sb.WriteByte('^')
+	if length > 1 {
+		sb.WriteString(strings.Repeat("~", length-1))
+	}
+
+	return sb.String()
+}
+
+func detailOf(err error) (ErrorDetail, bool) {
+
+	var lexerErr LexerError
+	if errors.As(err, &lexerErr) {
+		return lexerErr.ErrorDetail, true
+	}
+	var parserErr ParserError
+	if errors.As(err, &parserErr) {
+		return parserErr.ErrorDetail, true
+	}
+	var evaluateErr EvaluateError
+	if errors.As(err, &evaluateErr) {
+		return evaluateErr.ErrorDetail, true
+	}
+	var typeErr TypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.ErrorDetail, true
+	}
+
+	return ErrorDetail{}, false
+}
@@ -0,0 +1,62 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatError(t *testing.T) {
+	input := []struct {
+		expr     string
+		expected string
+	}{
+		{"label_01 &&\n label_03", "2:2: undefined variable:label_03\n label_03\n ^~~~~~~~\nhint: the variable has no value, check its spelling\n"},
+		{"label_01 && $x", "1:13: unexpected char:$\nlabel_01 && $x\n            ^\nhint: remove the character or put it inside a string literal\n"},
+		{"label_01 &&", "1:12: unexpected end of expression\nlabel_01 &&\n           ^\nhint: the expression is incomplete, an operand is missing\n"},
+		{"\t(label_01\r\n\t&& label_02", "1:2: unbalanced parenthesis, missing ')' for '('\n\t(label_01\n\t^\nhint: every '(' needs a matching ')' and every '[' a matching ']'\n"},
+		{"label_01 == 'abc", "1:13: unterminated string\nlabel_01 == 'abc\n            ^~~~\nhint: close the string with a single quote in the same line\n"},
+		{"", "empty stream\nhint: an expression needs at least one operand\n"},
+		{"'żółw' == zolw", "1:11: undefined variable:zolw\n'żółw' == zolw\n          ^~~~\nhint: the variable has no value, check its spelling\n"},
+		{"label_01 == 'ąę", "1:13: unterminated string\nlabel_01 == 'ąę\n            ^~~\nhint: close the string with a single quote in the same line\n"},
+	}
+
+	values := map[string]interface{}{
+		"label_01": true,
+		"label_02": false,
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.expr, values)
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error")
+			continue
+		}
+		if diag := FormatError(in.expr, err); diag != in.expected {
+			t.Errorf("unexpected result: %d\n%q\nexpected:\n%q", i, diag, in.expected)
+		}
+	}
+}
+
+func TestFormatError_List(t *testing.T) {
+
+	expr := "retries &&\n  label_01 || missing"
+	err := Check(expr, map[string]Type{"retries": TypeInt, "label_01": TypeBool})
+
+	expected := "1:9: can't apply && to int and bool\nretries &&\n        ^~\nhint: operands of this operator have incompatible types\n" +
+		"\n" +
+		"2:15: undefined variable:missing\n  label_01 || missing\n              ^~~~~~~\nhint: the variable has no value, check its spelling\n"
+
+	if diag := FormatError(expr, err); diag != expected {
+		t.Errorf("unexpected result:\n%q\nexpected:\n%q", diag, expected)
+	}
+}
+
+func TestFormatError_Other(t *testing.T) {
+
+	if diag := FormatError("label_01", nil); diag != "" {
+		t.Error("unexpected result:", diag)
+	}
+	if diag := FormatError("label_01", errors.New("other")); diag != "other\n" {
+		t.Error("unexpected result:", diag)
+	}
+}
@@ -1,6 +1,113 @@
 package expr
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCode identifies the kind of an error, codes are stable and can be matched with errors.Is
+type ErrorCode int
+
+const (
+	ErrUnknown            ErrorCode = 0
+	ErrEmptyExpression    ErrorCode = 1
+	ErrUnexpectedChar     ErrorCode = 2
+	ErrUnterminatedString ErrorCode = 3
+	ErrUnrecognizedToken  ErrorCode = 4
+	ErrInvalidNumber      ErrorCode = 5
+	ErrUnexpectedToken    ErrorCode = 6
+	ErrUnexpectedEnd      ErrorCode = 7
+	ErrUnbalancedParen    ErrorCode = 8
+	ErrUndefinedVariable  ErrorCode = 9
+	ErrUnsupportedType    ErrorCode = 10
+	ErrOutOfRange         ErrorCode = 11
+	ErrTypeMismatch       ErrorCode = 12
+	ErrDivisionByZero     ErrorCode = 13
+	ErrResolveFailed      ErrorCode = 14
+	ErrCanceled           ErrorCode = 15
+	ErrStepLimit          ErrorCode = 16
+	ErrUnknownResult      ErrorCode = 17
+	ErrUndefinedField     ErrorCode = 18
+	ErrIndexOutOfRange    ErrorCode = 19
+	ErrUndefinedFunction  ErrorCode = 20
+	ErrArgumentCount      ErrorCode = 21
+	ErrCallFailed         ErrorCode = 22
+	ErrInvalidFunction    ErrorCode = 23
+	ErrInvalidPattern     ErrorCode = 24
+	ErrInvalidTime        ErrorCode = 25
+	ErrUndefinedCalendar  ErrorCode = 26
+	ErrInvalidCron        ErrorCode = 27
+	ErrInvalidQualifier   ErrorCode = 28
+	ErrEmptyMember        ErrorCode = 29
+	ErrAmbiguousName      ErrorCode = 30
+)
+
+var errorCodeNames = map[ErrorCode]string{
+	ErrUnknown:            "unknown error",
+	ErrEmptyExpression:    "empty expression",
+	ErrUnexpectedChar:     "unexpected char",
+	ErrUnterminatedString: "unterminated string",
+	ErrUnrecognizedToken:  "unrecognized token",
+	ErrInvalidNumber:      "invalid number",
+	ErrUnexpectedToken:    "unexpected token",
+	ErrUnexpectedEnd:      "unexpected end of expression",
+	ErrUnbalancedParen:    "unbalanced parenthesis",
+	ErrUndefinedVariable:  "undefined variable",
+	ErrUnsupportedType:    "unsupported type",
+	ErrOutOfRange:         "value out of range",
+	ErrTypeMismatch:       "type mismatch",
+	ErrDivisionByZero:     "division by zero",
+	ErrResolveFailed:      "variable resolution failed",
+	ErrCanceled:           "evaluation canceled",
+	ErrStepLimit:          "step limit exceeded",
+	ErrUnknownResult:      "unknown result",
+	ErrUndefinedField:     "undefined field",
+	ErrIndexOutOfRange:    "index out of range",
+	ErrUndefinedFunction:  "undefined function",
+	ErrArgumentCount:      "wrong number of arguments",
+	ErrCallFailed:         "function call failed",
+	ErrInvalidFunction:    "invalid function",
+	ErrInvalidPattern:     "invalid pattern",
+	ErrInvalidTime:        "invalid time",
+	ErrUndefinedCalendar:  "undefined calendar",
+	ErrInvalidCron:        "invalid cron expression",
+	ErrInvalidQualifier:   "invalid qualifier",
+	ErrEmptyMember:        "empty member name",
+	ErrAmbiguousName:      "ambiguous name",
+}
+
+func (c ErrorCode) Error() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("error code %d", int(c))
+}
+
+// ErrorDetail holds the code and the location of an error, its fields are promoted to all error types.
+// Line and Column start from 1, Offset is a byte offset from the beginning of the expression,
+// Token is the text of the offending token. An error without a location has zero Line.
+type ErrorDetail struct {
+	Code   ErrorCode
+	Line   int
+	Column int
+	Offset int
+	Token  string
+	reason string
+}
+
+// Is reports if the target is the code of the error
+func (d ErrorDetail) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && code == d.Code
+}
+
+func detailAt(code ErrorCode, token ParserToken, reason string) ErrorDetail {
+	return ErrorDetail{Code: code, Line: token.line, Column: token.pos + 1, Offset: token.offset, Token: token.value, reason: reason}
+}
+
 type LexerError struct {
+	ErrorDetail
 	msg string
 }
 
@@ -12,7 +119,12 @@ func newLexerError(msg string) error {
 	return LexerError{msg: msg}
 }
 
+func newLexerErrorAt(code ErrorCode, token ParserToken, msg string) error {
+	return LexerError{ErrorDetail: detailAt(code, token, msg), msg: fmt.Sprintf("%s,line:%d,position:%d", msg, token.line, token.pos)}
+}
+
 type ParserError struct {
+	ErrorDetail
 	msg string
 }
 
@@ -24,14 +136,88 @@ func newParserError(msg string) error {
 	return ParserError{msg: msg}
 }
 
+func newParserErrorAt(code ErrorCode, token ParserToken, msg string) error {
+	return ParserError{ErrorDetail: detailAt(code, token, msg), msg: fmt.Sprintf("%s,line:%d,pos:%d", msg, token.line, token.pos)}
+}
+
 type EvaluateError struct {
-	msg string
+	ErrorDetail
+	msg   string
+	cause error
 }
 
 func (ee EvaluateError) Error() string {
 	return ee.msg
 }
 
+// Unwrap returns the error that caused the evaluation to fail, e.g. an error of a Resolver
+func (ee EvaluateError) Unwrap() error {
+	return ee.cause
+}
+
 func newEvaluateError(msg string) error {
 	return EvaluateError{msg: msg}
 }
+
+func newEvaluateErrorAt(code ErrorCode, token ParserToken, msg string) error {
+	return EvaluateError{ErrorDetail: detailAt(code, token, msg), msg: fmt.Sprintf("%s,line:%d,pos:%d", msg, token.line, token.pos)}
+}
+
+// wrapEvaluateError returns an evaluation error caused by another error
+func wrapEvaluateError(code ErrorCode, token ParserToken, msg string, cause error) error {
+	err := newEvaluateErrorAt(code, token, msg).(EvaluateError)
+	err.cause = cause
+	return err
+}
+
+type TypeError struct {
+	ErrorDetail
+	msg string
+}
+
+func (te TypeError) Error() string {
+	return te.msg
+}
+
+func newTypeError(msg string) error {
+	return TypeError{msg: msg}
+}
+
+func newTypeErrorAt(code ErrorCode, token ParserToken, msg string) error {
+	return TypeError{ErrorDetail: detailAt(code, token, msg), msg: fmt.Sprintf("%s,line:%d,pos:%d", msg, token.line, token.pos)}
+}
+
+// ErrorList is a list of errors found in a single expression
+type ErrorList []error
+
+func (el ErrorList) Error() string {
+
+	msgs := make([]string, len(el))
+	for i, err := range el {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Is reports if any error on the list matches the target
+func (el ErrorList) Is(target error) bool {
+
+	for _, err := range el {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error on the list that matches the target
+func (el ErrorList) As(target interface{}) bool {
+
+	for _, err := range el {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
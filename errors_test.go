@@ -1,6 +1,10 @@
 package expr
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
 
 func TestErrors(t *testing.T) {
 
@@ -18,4 +22,109 @@ func TestErrors(t *testing.T) {
 	if err.Error() != error_msg {
 		t.Error("unexpected result:", err.Error(), "expected:", error_msg)
 	}
+
+	err = newTypeError(error_msg)
+	if err.Error() != error_msg {
+		t.Error("unexpected result:", err.Error(), "expected:", error_msg)
+	}
+
+	err = ErrorList{newTypeError(error_msg), newParserError(error_msg)}
+	if err.Error() != error_msg+"\n"+error_msg {
+		t.Error("unexpected result:", err.Error(), "expected:", error_msg+"\n"+error_msg)
+	}
+}
+
+func TestErrors_Detail(t *testing.T) {
+	input := []struct {
+		expr   string
+		code   ErrorCode
+		line   int
+		column int
+		offset int
+		token  string
+	}{
+		{"", ErrEmptyExpression, 0, 0, 0, ""},
+		{"label_01 && $label", ErrUnexpectedChar, 1, 13, 12, "$"},
+		{"label_01 &&\n  label == 'abc", ErrUnterminatedString, 2, 12, 23, "'abc"},
+		{"label_01 &= label_02", ErrUnrecognizedToken, 1, 10, 9, "&="},
+		{"label_01 label_02", ErrUnexpectedToken, 1, 10, 9, "label_02"},
+		{"label_01 &&", ErrUnexpectedEnd, 1, 12, 11, ""},
+		{"(label_01 && label_02", ErrUnbalancedParen, 1, 1, 0, "("},
+		{"label_01\n && label_02)", ErrUnbalancedParen, 2, 13, 21, ")"},
+		{"label_01 &&\nlabel_03", ErrUndefinedVariable, 2, 1, 12, "label_03"},
+		{"label_01 && retries", ErrTypeMismatch, 1, 10, 9, "&&"},
+		{"retries / (retries - 3) > 1", ErrDivisionByZero, 1, 9, 8, "/"},
+		{"label_04", ErrUnsupportedType, 1, 1, 0, "label_04"},
+	}
+
+	values := map[string]interface{}{
+		"label_01": true,
+		"label_02": false,
+		"retries":  3,
+		"label_04": struct{}{},
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.expr, values)
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error")
+			continue
+		}
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected code:", in.code)
+		}
+
+		var detail ErrorDetail
+		switch e := err.(type) {
+		case LexerError:
+			detail = e.ErrorDetail
+		case ParserError:
+			detail = e.ErrorDetail
+		case EvaluateError:
+			detail = e.ErrorDetail
+		default:
+			t.Error("unexpected result:", i, "error type:", fmt.Sprintf("%T", err))
+			continue
+		}
+
+		if detail.Line != in.line || detail.Column != in.column || detail.Offset != in.offset || detail.Token != in.token {
+			t.Error("unexpected result:", i, "detail:", detail, "expected:", in.line, in.column, in.offset, in.token)
+		}
+	}
+}
+
+func TestErrors_IsAs(t *testing.T) {
+
+	err := Check("label_01 && retries || missing", map[string]Type{"label_01": TypeBool, "retries": TypeInt})
+
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Error("unexpected result:", err, "expected:", ErrTypeMismatch)
+	}
+	if !errors.Is(err, ErrUndefinedVariable) {
+		t.Error("unexpected result:", err, "expected:", ErrUndefinedVariable)
+	}
+	if errors.Is(err, ErrDivisionByZero) {
+		t.Error("unexpected result:", err, "not expected:", ErrDivisionByZero)
+	}
+
+	var typeErr TypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatal("unexpected result:", err)
+	}
+	if typeErr.Code != ErrTypeMismatch || typeErr.Line != 1 || typeErr.Column != 10 {
+		t.Error("unexpected result:", typeErr.ErrorDetail)
+	}
+
+	wrapped := fmt.Errorf("job condition: %w", newParserErrorAt(ErrUnbalancedParen, ParserToken{value: "(", line: 1}, "unbalanced parenthesis"))
+	if !errors.Is(wrapped, ErrUnbalancedParen) {
+		t.Error("unexpected result:", wrapped)
+	}
+	var parserErr ParserError
+	if !errors.As(wrapped, &parserErr) || parserErr.Token != "(" {
+		t.Error("unexpected result:", wrapped)
+	}
+
+	if ErrUndefinedVariable.Error() != "undefined variable" || ErrorCode(999).Error() != "error code 999" {
+		t.Error("unexpected result:", ErrUndefinedVariable.Error(), ErrorCode(999).Error())
+	}
 }
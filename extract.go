@@ -0,0 +1,122 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Span is a location of an identifier in the source of an expression, the column is counted in characters
+// from 1, the offset from the start of the source and the length are counted in bytes
+type Span struct {
+	Line   int
+	Column int
+	Offset int
+	Length int
+}
+
+// IdentifierInfo describes a distinct identifier of an expression, Type is the type the variable must have
+// given how it is used, it is valid only if Typed is set. Spans are all occurrences of the identifier
+type IdentifierInfo struct {
+	Identifier
+	Spans []Span
+	Type  Type
+	Typed bool
+}
+
+// ExtractInfo compiles an expression and returns its distinct identifiers in order of their first appearance
+func ExtractInfo(expr string) ([]IdentifierInfo, error) {
+
+	prog, err := Compile(expr)
+	if err != nil {
+		return []IdentifierInfo{}, err
+	}
+
+	return prog.Identifiers()
+}
+
+// Identifiers returns distinct identifiers of the program with types inferred from their usage:
+// an operand of a logical operator is bool, an operand compared with a literal has its type,
+// an argument of a function has the type of the parameter. An identifier used with types
+// that don't fit each other is an error
+func (p *Program) Identifiers() ([]IdentifierInfo, error) {
+
+	tc := &typeChecker{free: true, usage: &usage{kinds: map[*identExpr]valueT{}}}
+	p.root.check(tc)
+	tc.expect(p.root, boolValue)
+
+	idents := tc.usage.idents
+	sort.SliceStable(idents, func(i, j int) bool { return idents[i].token.offset < idents[j].token.offset })
+
+	infos := []IdentifierInfo{}
+	index := map[string]int{}
+	for _, ex := range idents {
+
+		n, ok := index[ex.name]
+		if !ok {
+			n, index[ex.name] = len(infos), len(infos)
+			infos = append(infos, IdentifierInfo{Identifier: Identifier{Name: ex.root, Path: ex.path, Qualifier: ex.qualifier}})
+		}
+
+		info := &infos[n]
+		info.Spans = append(info.Spans, Span{Line: ex.token.line, Column: ex.token.pos + 1, Offset: ex.token.offset, Length: ex.token.length})
+
+		kind, ok := tc.usage.kinds[ex]
+		if !ok {
+			continue
+		}
+		if !info.Typed {
+			info.Type, info.Typed = Type(kind), true
+			continue
+		}
+
+		merged, ok := mergeKinds(valueT(info.Type), kind)
+		if !ok {
+			return []IdentifierInfo{}, newTypeErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("conflicting types of %s:%s and %s", ex.name, info.Type, kind))
+		}
+		info.Type = Type(merged)
+	}
+
+	return infos, nil
+}
+
+// mergeKinds returns a type that fits two uses of a variable, a variable used as int and float is a float
+func mergeKinds(a, b valueT) (valueT, bool) {
+
+	switch {
+	case a == b:
+		return a, true
+	case isNumeric(a) && isNumeric(b):
+		return floatValue, true
+	}
+
+	return invalidValue, false
+}
+
+// arithOperandKind returns a type of an operand of an arithmetic operator given the type of the other operand,
+// it is invalidValue if the type is ambiguous, like for time or duration added to a duration
+func arithOperandKind(oper TokenValue, other valueT, otherLeft bool) valueT {
+
+	if isNumeric(other) {
+		return other
+	}
+	if !isTemporal(other) {
+		return invalidValue
+	}
+
+	kind := invalidValue
+	for _, candidate := range []valueT{intValue, timeValue, durationValue} {
+		left, right := candidate, other
+		if otherLeft {
+			left, right = other, candidate
+		}
+		if _, ok := timeArithKind(oper, left, right); !ok {
+			continue
+		}
+		if kind != invalidValue {
+			return invalidValue
+		}
+		kind = candidate
+	}
+
+	return kind
+}
@@ -0,0 +1,120 @@
+package expr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExtractInfo_Types(t *testing.T) {
+	input := []struct {
+		testCase string
+		name     string
+		typ      Type
+		typed    bool
+	}{
+		{"label_01", "label_01", TypeBool, true},
+		{"label_01.PREV && !label_02", "label_02", TypeBool, true},
+		{"runs > 2", "runs", TypeInt, true},
+		{"2.5 <= ratio", "ratio", TypeFloat, true},
+		{"status == 'OK'", "status", TypeString, true},
+		{"status in ['OK', 'ENDED']", "status", TypeString, true},
+		{"'OK' in states", "states", TypeList, true},
+		{"states[idx] == 'OK'", "idx", TypeInt, true},
+		{"name =~ '^job_'", "name", TypeString, true},
+		{"started < d'2026-10-17'", "started", TypeTime, true},
+		{"started - d'2026-10-17' > 1h", "started", TypeTime, true},
+		{"started + 1h > now", "started", TypeBool, false},
+		{"retries + 1 == 3", "retries", TypeInt, true},
+		{"is_weekend(odate)", "odate", TypeTime, true},
+		{"len(name) > 0", "name", TypeBool, false},
+		{"a == b", "a", TypeBool, false},
+		{"runs > 2 && runs < 2.5", "runs", TypeFloat, true},
+	}
+
+	for i, in := range input {
+		infos, err := ExtractInfo(in.testCase)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+			continue
+		}
+		found := false
+		for _, info := range infos {
+			if info.Name != in.name {
+				continue
+			}
+			found = true
+			if info.Typed != in.typed || (in.typed && info.Type != in.typ) {
+				t.Error("unexpected result:", i, "type:", info.Type, info.Typed, "expected:", in.typ, in.typed)
+			}
+		}
+		if !found {
+			t.Error("unexpected result:", i, "missing:", in.name)
+		}
+	}
+}
+
+func TestExtractInfo_Spans(t *testing.T) {
+
+	infos, err := ExtractInfo("label_01.PREV && runs > 2 ||\n label_01.PREV && label_01.Owner.NEXT")
+	expected := []IdentifierInfo{
+		{
+			Identifier: Identifier{Name: "label_01", Path: []string{}, Qualifier: "PREV"},
+			Spans:      []Span{{Line: 1, Column: 1, Offset: 0, Length: 13}, {Line: 2, Column: 2, Offset: 30, Length: 13}},
+			Type:       TypeBool, Typed: true,
+		},
+		{
+			Identifier: Identifier{Name: "runs", Path: []string{}},
+			Spans:      []Span{{Line: 1, Column: 18, Offset: 17, Length: 4}},
+			Type:       TypeInt, Typed: true,
+		},
+		{
+			Identifier: Identifier{Name: "label_01", Path: []string{"Owner"}, Qualifier: "NEXT"},
+			Spans:      []Span{{Line: 2, Column: 19, Offset: 47, Length: 19}},
+			Type:       TypeBool, Typed: true,
+		},
+	}
+
+	if err != nil || !reflect.DeepEqual(infos, expected) {
+		t.Error("unexpected result:", infos, "error:", err)
+	}
+}
+
+func TestExtractInfo_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"runs > 2 && runs", ErrTypeMismatch},
+		{"status == 'OK' || status > 1", ErrTypeMismatch},
+		{"label_01.PREV && label_01.PREV > 1", ErrTypeMismatch},
+		{"runs > ", ErrUnexpectedEnd},
+	}
+
+	for i, in := range input {
+		infos, err := ExtractInfo(in.testCase)
+		if !errors.Is(err, in.code) || len(infos) != 0 {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+}
+
+func TestExtractInfo_Env(t *testing.T) {
+
+	env := NewEnv()
+	env.Register("overdue", func(runs int, limit float64) bool { return float64(runs) > limit })
+
+	prog, err := env.Compile("overdue(label_01.Runs, limit)")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+
+	infos, err := prog.Identifiers()
+	if err != nil || len(infos) != 2 || infos[0].Type != TypeInt || infos[1].Type != TypeFloat {
+		t.Error("unexpected result:", infos, "error:", err)
+	}
+
+	if infos, err = env.ExtractInfo("overdue(runs, 1.5)"); err != nil || len(infos) != 1 || infos[0].Type != TypeInt {
+		t.Error("unexpected result:", infos, "error:", err)
+	}
+}
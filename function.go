@@ -0,0 +1,434 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Env is a set of functions available to expressions compiled with it. All functions should be registered
+// before the environment is used, Register is not safe for concurrent use with Compile
+type Env struct {
+	funcs      map[string]*function
+	calendars  *calendarSet
+	qualifiers *qualifierSet
+}
+
+// NewEnv returns an environment with built-in functions and default qualifiers, calendar functions
+// use calendars registered with the environment
+func NewEnv() *Env {
+
+	env := &Env{funcs: map[string]*function{}, calendars: newCalendarSet(), qualifiers: newQualifierSet()}
+	for name, f := range builtins {
+		env.funcs[name] = f
+	}
+	for name, f := range newBuiltins(env.calendars.funcs()) {
+		env.funcs[name] = f
+	}
+
+	return env
+}
+
+// Register adds a Go function to the environment, a registered function replaces a previous one with the same name.
+// Parameters of the function must be bool, integers, floats, strings, slices of them or interface{},
+// the function returns a single value of these types or a value and an error.
+// A function that returns an error fails the evaluation with ErrCallFailed
+func (e *Env) Register(name string, fn interface{}) error {
+
+	if tokens, err := tokenize(name); err != nil || len(tokens) != 1 || tokens[0].tokenType != tokenT_IDENT {
+		return TypeError{ErrorDetail: ErrorDetail{Code: ErrInvalidFunction}, msg: fmt.Sprintf("invalid function name:%s", name)}
+	}
+
+	f, err := newFunction(name, fn)
+	if err != nil {
+		return err
+	}
+	e.funcs[name] = f
+
+	return nil
+}
+
+// Compile compiles an expression like Compile, calls of functions are checked against the registered functions.
+// It takes place of Test for expressions of the environment
+func (e *Env) Compile(expr string) (*Program, error) {
+	return compile(expr, e)
+}
+
+// Validate checks an expression like Validate, calls of functions are checked against the registered functions
+func (e *Env) Validate(expr string) error {
+	return validate(expr, e)
+}
+
+// Check compiles an expression with the environment and verifies its types like Check
+func (e *Env) Check(expr string, schema map[string]Type) error {
+
+	prog, err := e.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	return prog.Check(schema)
+}
+
+// ExtractInfo compiles an expression with the environment and returns its identifiers like ExtractInfo
+func (e *Env) ExtractInfo(expr string) ([]IdentifierInfo, error) {
+
+	prog, err := e.Compile(expr)
+	if err != nil {
+		return []IdentifierInfo{}, err
+	}
+
+	return prog.Identifiers()
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// function is a registered Go function, kinds of its parameters and its result are known at compile time,
+// invalidValue is a kind of an interface{} parameter or result which accepts any value.
+// Built-in functions can check string literal arguments when an expression is compiled
+type function struct {
+	name     string
+	fn       reflect.Value
+	params   []valueT
+	result   valueT
+	fails    bool
+	literals []func(lit string) error
+}
+
+func newFunction(name string, fn interface{}) (*function, error) {
+
+	invalid := func(format string, args ...interface{}) error {
+		return TypeError{ErrorDetail: ErrorDetail{Code: ErrInvalidFunction}, msg: fmt.Sprintf("function:%s,", name) + fmt.Sprintf(format, args...)}
+	}
+
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return nil, invalid("expected func, got %T", fn)
+	}
+
+	t := rv.Type()
+	f := &function{name: name, fn: rv, params: make([]valueT, t.NumIn())}
+
+	for i := 0; i < t.NumIn(); i++ {
+		param := t.In(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			param = param.Elem()
+		}
+		kind, ok := kindOf(param)
+		if !ok {
+			return nil, invalid("unsupported type of parameter:%d, %s", i+1, param)
+		}
+		f.params[i] = kind
+	}
+
+	if t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		return nil, invalid("expected a single result or a result and an error")
+	}
+	result, ok := kindOf(t.Out(0))
+	if !ok {
+		return nil, invalid("unsupported type of result, %s", t.Out(0))
+	}
+	f.result = result
+	f.fails = t.NumOut() == 2
+
+	return f, nil
+}
+
+// kindOf returns a kind of values of the type, interface{} is invalidValue
+func kindOf(t reflect.Type) (valueT, bool) {
+
+	switch t {
+	case timeType:
+		return timeValue, true
+	case durationType:
+		return durationValue, true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return boolValue, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return intValue, true
+	case reflect.Float32, reflect.Float64:
+		return floatValue, true
+	case reflect.String:
+		return stringValue, true
+	case reflect.Slice:
+		if _, ok := kindOf(t.Elem()); ok {
+			return listValue, true
+		}
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return invalidValue, true
+		}
+	}
+
+	return 0, false
+}
+
+// param returns the kind of the parameter for the argument at i, the last parameter of a variadic function
+// takes all remaining arguments
+func (f *function) param(i int) valueT {
+
+	if i >= len(f.params) {
+		return f.params[len(f.params)-1]
+	}
+	return f.params[i]
+}
+
+// paramType returns the Go type of the parameter for the argument at i
+func (f *function) paramType(i int) reflect.Type {
+
+	t := f.fn.Type()
+	if t.IsVariadic() && i >= t.NumIn()-1 {
+		return t.In(t.NumIn() - 1).Elem()
+	}
+	return t.In(i)
+}
+
+func (f *function) accepts(args int) bool {
+
+	if f.fn.Type().IsVariadic() {
+		return args >= len(f.params)-1
+	}
+	return args == len(f.params)
+}
+
+func (f *function) arity() string {
+
+	if f.fn.Type().IsVariadic() {
+		return fmt.Sprintf("at least %d arguments", len(f.params)-1)
+	}
+	return fmt.Sprintf("%d arguments", len(f.params))
+}
+
+// callExpr is a call of a registered function
+type callExpr struct {
+	fn    *function
+	args  []exprNode
+	token ParserToken
+}
+
+func (ex *callExpr) evaluate(sc *scope) (valueNode, error) {
+
+	args := make([]reflect.Value, len(ex.args))
+	for i, arg := range ex.args {
+
+		v, err := sc.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		// an unknown argument makes the result unknown, with the default policy the argument is a zero value
+		if v.isValue() == unknownValue {
+			if sc.options.undefined != UndefinedDefault {
+				return v, nil
+			}
+			args[i] = reflect.Zero(ex.fn.paramType(i))
+			continue
+		}
+		// unknown items of a list are settled like unknown arguments, convertArg passes them as zero values
+		if hasUnknown(v) && sc.options.undefined != UndefinedDefault {
+			return unknownValueNode, nil
+		}
+
+		if args[i], err = convertArg(v, ex.fn.paramType(i)); err != nil {
+			return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't pass %s as argument:%d of %s,%s", v.isValue(), i+1, ex.fn.name, err))
+		}
+	}
+
+	out := ex.fn.fn.Call(args)
+	if ex.fn.fails && !out[1].IsNil() {
+		err := out[1].Interface().(error)
+		return nil, wrapEvaluateError(ErrCallFailed, ex.token, fmt.Sprintf("function:%s failed,%s", ex.fn.name, err), err)
+	}
+
+	node, err := createValueExprNode(out[0].Interface())
+	if err != nil {
+		code := ErrUnsupportedType
+		if errors.Is(err, ErrOutOfRange) {
+			code = ErrOutOfRange
+		}
+		return nil, newEvaluateErrorAt(code, ex.token, fmt.Sprintf("function:%s,%s", ex.fn.name, err))
+	}
+
+	return node, nil
+}
+
+func (ex *callExpr) check(tc *typeChecker) valueT {
+
+	for i, arg := range ex.args {
+		t, param := arg.check(tc), ex.fn.param(i)
+		tc.expect(arg, param)
+		if t == invalidValue || param == invalidValue || t == param || (t == intValue && param == floatValue) {
+			continue
+		}
+		tc.reportCall(ErrTypeMismatch, ex.token, "can't pass %s as argument:%d of %s, expected %s", t, i+1, ex.fn.name, param)
+	}
+
+	return ex.fn.result
+}
+
+// checkLiterals checks string literal arguments of a call, starts are the first tokens of the arguments,
+// an error is located at the literal
+func (f *function) checkLiterals(name ParserToken, args []exprNode, starts []ParserToken) error {
+
+	for i, arg := range args {
+		lit, ok := arg.(*stringValueExpr)
+		if !ok || i >= len(f.literals) || f.literals[i] == nil {
+			continue
+		}
+		err := f.literals[i](lit.val)
+		if err == nil {
+			continue
+		}
+
+		token := starts[i]
+		if token.tokenType != tokenT_STRVAL {
+			token = name
+		}
+		code := ErrCallFailed
+		if detail, ok := detailOf(err); ok {
+			code = detail.Code
+		}
+		return newParserErrorAt(code, token, err.Error())
+	}
+
+	return nil
+}
+
+// convertArg converts a value to the type of a parameter
+func convertArg(v valueNode, t reflect.Type) (reflect.Value, error) {
+
+	mismatch := fmt.Errorf("expected %s", t)
+
+	if v.isValue() == unknownValue {
+		return reflect.Zero(t), nil
+	}
+
+	if t == timeType || t == durationType {
+		native := reflect.ValueOf(nativeOf(v))
+		if native.Type() != t {
+			return reflect.Value{}, mismatch
+		}
+		return native, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		native := nativeOf(v)
+		if native == nil {
+			return reflect.Value{}, fmt.Errorf("unsupported value:%s", v.isValue())
+		}
+		return reflect.ValueOf(native), nil
+	case reflect.Bool, reflect.String:
+		native := reflect.ValueOf(nativeOf(v))
+		if native.Kind() != t.Kind() {
+			return reflect.Value{}, mismatch
+		}
+		return native.Convert(t), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var val int
+		if v.isValue() != intValue {
+			return reflect.Value{}, mismatch
+		}
+		v.value(&val)
+		out := reflect.New(t).Elem()
+		if out.OverflowInt(int64(val)) {
+			return reflect.Value{}, fmt.Errorf("value out of range:%d", val)
+		}
+		out.SetInt(int64(val))
+		return out, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var val int
+		if v.isValue() != intValue {
+			return reflect.Value{}, mismatch
+		}
+		v.value(&val)
+		out := reflect.New(t).Elem()
+		if val < 0 || out.OverflowUint(uint64(val)) {
+			return reflect.Value{}, fmt.Errorf("value out of range:%d", val)
+		}
+		out.SetUint(uint64(val))
+		return out, nil
+	case reflect.Float32, reflect.Float64:
+		if !isNumeric(v.isValue()) {
+			return reflect.Value{}, mismatch
+		}
+		return reflect.ValueOf(floatOf(v)).Convert(t), nil
+	case reflect.Slice:
+		var items []valueNode
+		if v.isValue() != listValue {
+			return reflect.Value{}, mismatch
+		}
+		v.value(&items)
+		out := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			elem, err := convertArg(item, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("item:%d,%s", i, err)
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	}
+
+	return reflect.Value{}, mismatch
+}
+
+// hasUnknown reports if a value is unknown or a list holding an unknown item
+func hasUnknown(v valueNode) bool {
+
+	switch v.isValue() {
+	case unknownValue:
+		return true
+	case listValue:
+		var items []valueNode
+		v.value(&items)
+		for _, item := range items {
+			if hasUnknown(item) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nativeOf returns a Go value of a value node, lists are []interface{}, an unknown value is nil
+func nativeOf(v valueNode) interface{} {
+
+	switch v.isValue() {
+	case boolValue:
+		var val bool
+		v.value(&val)
+		return val
+	case intValue:
+		var val int
+		v.value(&val)
+		return val
+	case floatValue:
+		var val float64
+		v.value(&val)
+		return val
+	case stringValue:
+		var val string
+		v.value(&val)
+		return val
+	case timeValue:
+		return timeOf(v)
+	case durationValue:
+		return durationOf(v)
+	case listValue:
+		var items []valueNode
+		v.value(&items)
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			out[i] = nativeOf(item)
+		}
+		return out
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type runStatus string
+
+func testEnv(t *testing.T) *Env {
+
+	env := NewEnv()
+	funcs := map[string]interface{}{
+		"len":   func(s string) int { return len(s) },
+		"count": func(items []interface{}) int { return len(items) },
+		"sum": func(values ...float64) float64 {
+			s := 0.0
+			for _, v := range values {
+				s += v
+			}
+			return s
+		},
+		"join":       func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"is_holiday": func(date string) bool { return date == "2026-12-25" },
+		"status":     func(code int8) runStatus { return runStatus(fmt.Sprint("S", code)) },
+		"small":      func(v uint8) int { return int(v) },
+		"any":        func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"ids":        func() []int { return []int{1, 2, 3} },
+		"since": func(date string) (int, error) {
+			if date == "" {
+				return 0, errors.New("empty date")
+			}
+			return 3, nil
+		},
+		"label.age": func() int { return 7 },
+	}
+	for name, fn := range funcs {
+		if err := env.Register(name, fn); err != nil {
+			t.Fatal("unexpected result:", name, "error:", err)
+		}
+	}
+
+	return env
+}
+
+func TestFunction_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"len('abc') == 3", true, nil},
+		{"len(label_03) > 2 && is_holiday(run_date)", true, nil},
+		{"is_holiday('2026-12-24')", false, nil},
+		{"count([1, 'a', true]) == 3", true, nil},
+		{"count(runs) == 2", true, nil},
+		{"sum() == 0", true, nil},
+		{"sum(1, 2.5, retries) == 5.5", true, nil},
+		{"join('-', 'a', 'b') == 'a-b'", true, nil},
+		{"status(4) == 'S4'", true, nil},
+		{"small(255) == 255", true, nil},
+		{"any(1) == 'int' && any(1.5) == 'float64' && any([1]) == '[]interface {}'", true, nil},
+		{"2 in ids() && ids()[2] == 3", true, nil},
+		{"since(label_03) + label.age() == 10", true, nil},
+		{"len((label_03)) == 3", true, nil},
+		{"!is_holiday(label_03)", true, nil},
+	}
+
+	env := testEnv(t)
+	values := map[string]interface{}{
+		"label_03": "abc",
+		"run_date": "2026-12-25",
+		"retries":  2,
+		"runs":     []string{"a", "b"},
+	}
+
+	for i, in := range input {
+		prog, err := env.Compile(in.testCase)
+		if in.expectedError != nil {
+			if err == nil {
+				t.Error("unexpected result:", i, "expected error")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+			continue
+		}
+		r, err := prog.Eval(values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestFunction_CompileErrors(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+		message  string
+	}{
+		{"missing(1)", ErrUndefinedFunction, "undefined function:missing,line:1,pos:0"},
+		{"len()", ErrArgumentCount, "function:len expects 1 arguments, got 0"},
+		{"len('a', 'b')", ErrArgumentCount, "function:len expects 1 arguments, got 2"},
+		{"join()", ErrArgumentCount, "function:join expects at least 1 arguments, got 0"},
+		{"len(1) == 1", ErrTypeMismatch, "can't pass int as argument:1 of len, expected string"},
+		{"sum(1, 'a') == 1", ErrTypeMismatch, "can't pass string as argument:2 of sum, expected float"},
+		{"join('-', 'a', 1) == ''", ErrTypeMismatch, "can't pass int as argument:3 of join, expected string"},
+		{"count(len('a'))", ErrTypeMismatch, "can't pass int as argument:1 of count, expected list"},
+		{"len(sum(1)) == 1", ErrTypeMismatch, "can't pass float as argument:1 of len, expected string"},
+		{"len('a'", ErrUnbalancedParen, "unbalanced parenthesis, missing ')' for '('"},
+		{"len('a' 'b')", ErrUnexpectedToken, "unexpected token:'b'"},
+		{"len('a',)", ErrUnexpectedToken, "unexpected token:)"},
+	}
+
+	env := testEnv(t)
+
+	for i, in := range input {
+		_, err := env.Compile(in.testCase)
+		if !errors.Is(err, in.code) || !strings.Contains(err.Error(), in.message) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code, in.message)
+		}
+	}
+}
+
+func TestFunction_EvaluateErrors(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"len(label_01) == 1", ErrTypeMismatch},
+		{"count(label_03) == 1", ErrTypeMismatch},
+		{"status(retries) == ''", ErrTypeMismatch},
+		{"small(-1) == 1", ErrTypeMismatch},
+		{"since('') == 1", ErrCallFailed},
+		{"join('-', runs) == ''", ErrTypeMismatch},
+	}
+
+	env := testEnv(t)
+	values := map[string]interface{}{
+		"label_01": true,
+		"label_03": "abc",
+		"retries":  1000,
+		"runs":     []string{"a"},
+	}
+
+	for i, in := range input {
+		prog, err := env.Compile(in.testCase)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+			continue
+		}
+		_, err = prog.Eval(values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+
+	prog, _ := env.Compile("since('') == 1")
+	_, err := prog.Eval(nil)
+	var cause EvaluateError
+	if !errors.As(err, &cause) || errors.Unwrap(cause) == nil || errors.Unwrap(cause).Error() != "empty date" {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestFunction_Undefined(t *testing.T) {
+
+	env := testEnv(t)
+
+	prog, err := env.Compile("len(missing) == 0")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+
+	r, err := prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedDefault))
+	if err != nil || r != True {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+	r, err = prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedUnknown))
+	if err != nil || r != Unknown {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	// an unknown item of a list argument
+	prog, err = env.Compile("count([zz]) == 1 && sum(1, 2) == 3")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+
+	r, err = prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedUnknown))
+	if err != nil || r != Unknown {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+	r, err = prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedDefault))
+	if err != nil || r != True {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+}
+
+func TestFunction_Register(t *testing.T) {
+	input := []struct {
+		name string
+		fn   interface{}
+	}{
+		{"", func() bool { return true }},
+		{"true", func() bool { return true }},
+		{"in", func() bool { return true }},
+		{"my func", func() bool { return true }},
+		{"1abc", func() bool { return true }},
+		{"f", nil},
+		{"f", 15},
+		{"f", (func() bool)(nil)},
+		{"f", func() {}},
+		{"f", func() (bool, bool) { return true, true }},
+		{"f", func() (bool, error, error) { return true, nil, nil }},
+		{"f", func(m map[string]int) bool { return true }},
+		{"f", func() struct{} { return struct{}{} }},
+		{"f", func(e error) bool { return true }},
+	}
+
+	env := NewEnv()
+	for i, in := range input {
+		if err := env.Register(in.name, in.fn); !errors.Is(err, ErrInvalidFunction) {
+			t.Error("unexpected result:", i, "error:", err)
+		}
+	}
+}
+
+func TestFunction_WithoutEnv(t *testing.T) {
+
+	if _, err := Compile("is_holiday('2026-12-25')"); !errors.Is(err, ErrUndefinedFunction) {
+		t.Error("unexpected result:", err)
+	}
+
+	vars, err := Extract("len(label_01) > 1 && label_02")
+	if err != nil || len(vars) != 2 || vars[0] != "label_01" || vars[1] != "label_02" {
+		t.Error("unexpected result:", vars, "error:", err)
+	}
+}
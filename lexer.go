@@ -1,10 +1,10 @@
 package expr
 
 import (
-	"errors"
 	"fmt"
 	"regexp"
 	"unicode"
+	"unicode/utf8"
 )
 
 type TokenValue string
@@ -15,8 +15,25 @@ const (
 	token_NOT       TokenValue = "!="
 	token_NEG       TokenValue = "!"
 	token_CMP       TokenValue = "=="
+	token_MATCH     TokenValue = "=~"
+	token_NOTMATCH  TokenValue = "!~"
+	token_LT        TokenValue = "<"
+	token_LE        TokenValue = "<="
+	token_GT        TokenValue = ">"
+	token_GE        TokenValue = ">="
+	token_ADD       TokenValue = "+"
+	token_SUB       TokenValue = "-"
+	token_MUL       TokenValue = "*"
+	token_DIV       TokenValue = "/"
+	token_MOD       TokenValue = "%"
 	token_BRACKET_R TokenValue = ")"
 	token_BRACKET_L TokenValue = "("
+	token_SQUARE_R  TokenValue = "]"
+	token_SQUARE_L  TokenValue = "["
+	token_COMMA     TokenValue = ","
+	token_IN        TokenValue = "in"
+	token_NOTKW     TokenValue = "not"
+	token_NOTIN     TokenValue = "not in"
 	token_TRUE      TokenValue = "true"
 	token_FALSE     TokenValue = "false"
 	token_EMPTY     TokenValue = ""
@@ -34,28 +51,51 @@ const (
 	tokenT_LPAR   TokenType = 7
 	tokenT_RPAR   TokenType = 8
 	tokenT_LOPER  TokenType = 9
+	tokenT_FLOAT  TokenType = 10
+	// tokenT_INVALID takes place of a malformed token when errors are recovered
+	tokenT_INVALID TokenType = 11
+	tokenT_LSQR    TokenType = 12
+	tokenT_RSQR    TokenType = 13
+	tokenT_COMMA   TokenType = 14
+	// tokenT_TIME is a date literal d'2006-01-02' or a timestamp literal t'2006-01-02T15:04:05Z'
+	tokenT_TIME     TokenType = 15
+	tokenT_DURATION TokenType = 16
 )
 
+// ParserToken is a token of an expression, pos is a position of its first character in the line,
+// offset and length are counted in bytes of the source
 type ParserToken struct {
 	tokenType TokenType
 	value     string
 	length    int
 	line      int
 	pos       int
+	offset    int
+}
+
+// chars returns the number of characters the token spans in the source
+func (t ParserToken) chars() int {
+	return t.length - len(t.value) + utf8.RuneCountInString(t.value)
 }
 
 type lexerState struct {
-	stream string
-	next   rune
-	buffer string
-	output []ParserToken
-	err    error
-	line   int32
-	pos    int32
+	stream  string
+	next    rune
+	buffer  string
+	output  []ParserToken
+	err     error
+	line    int32
+	pos     int32
+	offset  int32
+	width   int32
+	recover bool
+	errs    ErrorList
 }
 
 type lexerFunc func(lexer *lexerState) lexerFunc
 
+// Extract returns names of variables of an expression as they are written,
+// ExtractIdentifiers splits them into names, paths and qualifiers
 func Extract(expr string) ([]string, error) {
 	tokens, err := tokenize(expr)
 	variables := []string{}
@@ -63,7 +103,11 @@ func Extract(expr string) ([]string, error) {
 	if err != nil {
 		return []string{}, err
 	}
-	for _, t := range tokens {
+	for n, t := range tokens {
+		// a name of a called function is not a variable
+		if n+1 < len(tokens) && tokens[n+1].tokenType == tokenT_LPAR {
+			continue
+		}
 		if t.tokenType == tokenT_IDENT {
 			variables = append(variables, t.value)
 		}
@@ -96,12 +140,12 @@ func Translate(expr string) (string, []string, error) {
 					if tokens[n+1].value != "==" {
 
 						result = result + t.value + " == "
-						t = ParserToken{tokenT_CONS, "true", 4, t.line, t.pos}
+						t = ParserToken{tokenT_CONS, "true", 4, t.line, t.pos, t.offset}
 						spc = " "
 					}
 				} else {
 					result = result + t.value + " == "
-					t = ParserToken{tokenT_CONS, "true", 4, t.line, t.pos}
+					t = ParserToken{tokenT_CONS, "true", 4, t.line, t.pos, t.offset}
 					spc = " "
 				}
 
@@ -119,19 +163,36 @@ func Translate(expr string) (string, []string, error) {
 
 func tokenize(expr string) ([]ParserToken, error) {
 
+	lexer := runLexer(expr, false)
+	return lexer.output, lexer.err
+}
+
+// tokenizeAll doesn't stop at the first error, it returns tokens and all errors found in the expression
+func tokenizeAll(expr string) ([]ParserToken, ErrorList) {
+
+	lexer := runLexer(expr, true)
+	return lexer.output, lexer.errs
+}
+
+func runLexer(expr string, recover bool) *lexerState {
+
 	if len(expr) == 0 {
-		return nil, errors.New("empty stream")
+		err := LexerError{ErrorDetail: ErrorDetail{Code: ErrEmptyExpression}, msg: "empty stream"}
+		return &lexerState{err: err, errs: ErrorList{err}}
 	}
 
 	var state lexerFunc = lexEmpty
 	lexer := &lexerState{
-		stream: expr,
-		next:   rune(expr[0]),
-		buffer: "",
-		output: []ParserToken{},
-		line:   1,
-		pos:    -1,
-		err:    nil,
+		stream:  expr,
+		next:    rune(expr[0]),
+		buffer:  "",
+		output:  []ParserToken{},
+		line:    1,
+		pos:     -1,
+		offset:  -1,
+		width:   1,
+		err:     nil,
+		recover: recover,
 	}
 
 	lexer.move()
@@ -140,7 +201,7 @@ func tokenize(expr string) ([]ParserToken, error) {
 		state = state(lexer)
 	}
 
-	return lexer.output, lexer.err
+	return lexer
 }
 
 func lexEmpty(state *lexerState) lexerFunc {
@@ -152,7 +213,7 @@ func lexEmpty(state *lexerState) lexerFunc {
 		return lexIdent(state)
 	}
 
-	if unicode.IsDigit(state.next) && len(state.buffer) == 0 {
+	if (unicode.IsDigit(state.next) || state.next == '.') && len(state.buffer) == 0 {
 		return lexNumber(state)
 	}
 
@@ -160,55 +221,105 @@ func lexEmpty(state *lexerState) lexerFunc {
 		return lexString(state)
 	}
 
-	if state.next == '(' || state.next == ')' {
+	if state.next == '(' || state.next == ')' || isListChar(state.next) || isArithChar(state.next) {
 		state.buffer = state.buffer + string(state.next)
-		if t, err := state.classify(); err == nil {
-			state.produce(t)
-			state.buffer = ""
-			state.move()
-		} else {
-			state.err = newLexerError(fmt.Sprintf("unexpected char:%c,line:%d,position:%d", state.next, state.line, state.pos))
-			return nil
+		t, err := state.classify()
+		state.move()
+		if err != nil {
+			return state.fail(err)
 		}
+		state.produce(t)
+		state.buffer = ""
 
 		return lexEmpty
 	}
 
-	if state.next == '!' || state.next == '|' || state.next == '&' || state.next == '=' {
+	if isOperChar(state.next) {
 		return lexOper(state)
 	}
 
 	if state.next == 0x00 {
 		return nil
 	}
-	state.err = newLexerError(fmt.Sprintf("unexpected result line:%d,position:%d", state.line, state.pos))
-	return nil
+	err := state.charError()
+	state.move()
+	return state.fail(err)
 }
 
 func (lex *lexerState) produce(tp TokenType) {
+	lex.output = append(lex.output, lex.token(tp))
+}
 
-	lex.output = append(lex.output, ParserToken{
+// token returns a token made of the buffer, the buffer ends right before the next char
+func (lex *lexerState) token(tp TokenType) ParserToken {
+	return ParserToken{
 		tokenType: tp,
 		value:     lex.buffer,
 		length:    len(lex.buffer),
-		pos:       int(lex.pos) - len(lex.buffer),
+		pos:       int(lex.pos) - utf8.RuneCountInString(lex.buffer),
+		offset:    int(lex.offset) - len(lex.buffer),
 		line:      int(lex.line),
-	})
+	}
+}
+
+// charError returns an error pointing at the next char
+func (lex *lexerState) charError() error {
+
+	char := ParserToken{value: string(lex.next), length: int(lex.width), line: int(lex.line), pos: int(lex.pos), offset: int(lex.offset)}
+	if lex.next == 0x00 {
+		char.value, char.length = "", 0
+	}
+	return newLexerErrorAt(ErrUnexpectedChar, char, fmt.Sprintf("unexpected char:%c", lex.next))
+}
+
+// fail records an error and stops lexing. In the recovery mode lexing goes on, a non empty buffer
+// is turned into an invalid token, so the parser doesn't report a missing operand in its place
+func (lex *lexerState) fail(err error) lexerFunc {
+
+	if lex.err == nil {
+		lex.err = err
+	}
+	lex.errs = append(lex.errs, err)
+
+	if !lex.recover {
+		return nil
+	}
+
+	if lex.buffer != "" {
+		lex.produce(tokenT_INVALID)
+		lex.buffer = ""
+	}
 
+	return lexEmpty
 }
+
+// move reads the next character of the stream, pos is advanced by one character and offset by its size in bytes
 func (lex *lexerState) move() {
 	if lex.stream == "" {
-		lex.next = 0x00
+		if lex.next != 0x00 {
+			lex.pos++
+			lex.offset += lex.width
+		}
+		lex.next, lex.width = 0x00, 0
 		return
 	}
-	lex.next, lex.stream = rune(lex.stream[0]), lex.stream[1:]
+	r, size := utf8.DecodeRuneInString(lex.stream)
+	lex.offset += lex.width
+	lex.next, lex.stream, lex.width = r, lex.stream[size:], int32(size)
 	lex.pos++
 }
-func (lex *lexerState) classify() (TokenType, error) {
 
-	rLiteral := regexp.MustCompile(`^[A-Za-z][\w\d_\.\-]*$`)
-	nLiteral := regexp.MustCompile(`^\-?\d+$`)
-	strLiteral := regexp.MustCompile(`^\'[^\t\n\'\r]*'$`)
+// literal patterns classify tokens, they are compiled once
+var (
+	rLiteral   = regexp.MustCompile(`^[A-Za-z][\w\d_\.\-]*$`)
+	nLiteral   = regexp.MustCompile(`^\-?\d+$`)
+	fLiteral   = regexp.MustCompile(`^\-?(\d+\.\d*|\.\d+|\d+)([eE][\+\-]?\d+)?$`)
+	strLiteral = regexp.MustCompile(`^\'[^\t\n\'\r]*'$`)
+	tLiteral   = regexp.MustCompile(`^[dt]\'[^\t\n\'\r]*'$`)
+	dLiteral   = regexp.MustCompile(`^(\d+(\.\d*)?(ns|us|ms|s|m|h))+$`)
+)
+
+func (lex *lexerState) classify() (TokenType, error) {
 
 	switch true {
 	case lex.buffer == string(token_BRACKET_L):
@@ -219,10 +330,48 @@ func (lex *lexerState) classify() (TokenType, error) {
 		{
 			return tokenT_RPAR, nil
 		}
+	case lex.buffer == string(token_SQUARE_L):
+		{
+			return tokenT_LSQR, nil
+		}
+	case lex.buffer == string(token_SQUARE_R):
+		{
+			return tokenT_RSQR, nil
+		}
+	case lex.buffer == string(token_COMMA):
+		{
+			return tokenT_COMMA, nil
+		}
+	case lex.buffer == string(token_IN):
+		fallthrough
+	case lex.buffer == string(token_NOTKW):
+		fallthrough
 	case lex.buffer == string(token_CMP):
 		fallthrough
+	case lex.buffer == string(token_MATCH):
+		fallthrough
+	case lex.buffer == string(token_NOTMATCH):
+		fallthrough
 	case lex.buffer == string(token_NOT):
 		fallthrough
+	case lex.buffer == string(token_LT):
+		fallthrough
+	case lex.buffer == string(token_LE):
+		fallthrough
+	case lex.buffer == string(token_GT):
+		fallthrough
+	case lex.buffer == string(token_GE):
+		fallthrough
+	case lex.buffer == string(token_ADD):
+		fallthrough
+	case lex.buffer == string(token_SUB):
+		fallthrough
+	case lex.buffer == string(token_MUL):
+		fallthrough
+	case lex.buffer == string(token_DIV):
+		fallthrough
+	case lex.buffer == string(token_MOD):
+		fallthrough
 	case lex.buffer == string(token_OR):
 		fallthrough
 	case lex.buffer == string(token_AND):
@@ -245,13 +394,25 @@ func (lex *lexerState) classify() (TokenType, error) {
 		{
 			return tokenT_NUMBER, nil
 		}
+	case fLiteral.Match([]byte(lex.buffer)):
+		{
+			return tokenT_FLOAT, nil
+		}
 	case strLiteral.Match([]byte(lex.buffer)):
 		{
 			return tokenT_STRVAL, nil
 		}
+	case tLiteral.Match([]byte(lex.buffer)):
+		{
+			return tokenT_TIME, nil
+		}
+	case dLiteral.Match([]byte(lex.buffer)):
+		{
+			return tokenT_DURATION, nil
+		}
 	}
 
-	return 0, newLexerError(fmt.Sprintf("unrecognized token:%s,line:%d,position:%d", lex.buffer, lex.line, lex.pos))
+	return 0, newLexerErrorAt(ErrUnrecognizedToken, lex.token(0), fmt.Sprintf("unrecognized token:%s", lex.buffer))
 }
 
 func lexWS(state *lexerState) lexerFunc {
@@ -264,7 +425,7 @@ func lexWS(state *lexerState) lexerFunc {
 	} else if state.next == '\n' {
 		state.line++
 		state.move()
-		state.pos = 1
+		state.pos = 0
 		return lexWS
 
 	} else {
@@ -278,32 +439,58 @@ func lexIdent(state *lexerState) lexerFunc {
 	state.buffer = state.buffer + string(state.next)
 	state.move()
 
+	// d or t directly followed by a quote is a prefix of a date or a timestamp literal
+	if state.next == '\'' && (state.buffer == "d" || state.buffer == "t") {
+		return lexString
+	}
+
 	if (unicode.IsDigit(state.next) && len(state.buffer) != 0) || unicode.IsLetter(state.next) || state.next == '_' || state.next == '-' || state.next == '.' {
 		return lexIdent
 	} else {
-		if t, err := state.classify(); err == nil {
+		t, err := state.classify()
+		if err != nil {
+			return state.fail(err)
+		}
+		if t == tokenT_OPER && state.buffer == string(token_IN) {
+			state.produceIn()
+		} else {
 			state.produce(t)
-			state.buffer = ""
-			return lexEmpty
 		}
-		return nil
+		state.buffer = ""
+		return lexEmpty
 	}
 }
 
+// produceIn produces the in operator, a preceding not is merged with it into a single not in operator
+func (lex *lexerState) produceIn() {
+
+	token := lex.token(tokenT_OPER)
+	if n := len(lex.output); n != 0 && lex.output[n-1].tokenType == tokenT_OPER && lex.output[n-1].value == string(token_NOTKW) {
+		not := lex.output[n-1]
+		not.value = string(token_NOTIN)
+		not.length = token.offset + token.length - not.offset
+		token = not
+		lex.output = lex.output[:n-1]
+	}
+
+	lex.output = append(lex.output, token)
+}
+
 func lexNumber(state *lexerState) lexerFunc {
 
 	state.buffer = state.buffer + string(state.next)
 	state.move()
 
-	if unicode.IsDigit(state.next) {
+	if isNumberChar(state.next, state.buffer) {
 		return lexNumber
 	} else {
-		if t, err := state.classify(); err == nil {
-			state.produce(t)
-			state.buffer = ""
-			return lexEmpty
+		t, err := state.classify()
+		if err != nil {
+			return state.fail(err)
 		}
-		return nil
+		state.produce(t)
+		state.buffer = ""
+		return lexEmpty
 	}
 }
 
@@ -312,27 +499,30 @@ func lexString(state *lexerState) lexerFunc {
 	state.buffer = state.buffer + string(state.next)
 	state.move()
 
+	if state.next == 0x00 || state.next == '\n' {
+		return state.fail(newLexerErrorAt(ErrUnterminatedString, state.token(tokenT_STRVAL), "unterminated string"))
+	}
+
 	if state.next == '\'' {
 
 		state.buffer = state.buffer + string(state.next)
 		state.move()
 
-		if state.next == ' ' || state.next == '\t' || state.next == '\r' || state.next == 0x00 {
+		t, err := state.classify()
+		if err != nil {
+			return state.fail(err)
+		}
+		state.produce(t)
+		state.buffer = ""
 
-			if t, err := state.classify(); err == nil {
-				state.produce(t)
-				state.buffer = ""
-				return lexEmpty
-			}
-			return nil
-		} else {
-			state.err = newLexerError(fmt.Sprintf("unexpected char:%c,line:%d,position:%d", state.next, state.line, state.pos))
+		// a string can't be directly followed by another literal or an identifier
+		if state.next == '\'' || state.next == '_' || unicode.IsLetter(state.next) || unicode.IsDigit(state.next) {
+			return state.fail(state.charError())
 		}
-	} else {
-		return lexString
+		return lexEmpty
 	}
 
-	return nil
+	return lexString
 }
 
 func lexOper(state *lexerState) lexerFunc {
@@ -340,21 +530,63 @@ func lexOper(state *lexerState) lexerFunc {
 	state.buffer = state.buffer + string(state.next)
 	state.move()
 
-	if state.next == '!' || state.next == '|' || state.next == '&' || state.next == '=' {
+	if continuesOper(state.buffer, state.next) {
 		return lexOper
 	} else {
-		if t, err := state.classify(); err == nil {
-			state.produce(t)
-			state.buffer = ""
-			if t == tokenT_LOPER && unicode.IsSpace(state.next) {
-				state.err = newLexerError(fmt.Sprintf("unexpected char:%c,line:%d,position:%d", state.next, state.line, state.pos))
-				return nil
-			}
-			return lexEmpty
-		} else {
-			state.err = newLexerError(fmt.Sprintf("unexpected char:%c,line:%d,position:%d", state.next, state.line, state.pos))
-			return nil
+		t, err := state.classify()
+		if err != nil {
+			return state.fail(err)
 		}
+		state.produce(t)
+		state.buffer = ""
+		if t == tokenT_LOPER && unicode.IsSpace(state.next) {
+			return state.fail(state.charError())
+		}
+		return lexEmpty
+	}
+
+}
+
+// continuesOper reports if r is a part of the operator in the buffer, ! is an operator of its own
+// unless it starts != or !~, so !!a and a&&!b are lexed as separate operators
+func continuesOper(buffer string, r rune) bool {
+
+	if !isOperChar(r) || r == '!' {
+		return false
 	}
+	if buffer == string(token_NEG) {
+		return r == '=' || r == '~'
+	}
+	return true
+}
+
+// isNumberChar reports if r continues a number or a duration literal, a sign is part of a number
+// only right after an exponent
+func isNumberChar(r rune, buffer string) bool {
+
+	if unicode.IsDigit(r) || r == '.' || r == 'e' || r == 'E' || isUnitChar(r) {
+		return true
+	}
+	if (r == '+' || r == '-') && len(buffer) != 0 {
+		last := buffer[len(buffer)-1]
+		return last == 'e' || last == 'E'
+	}
+	return false
+}
+
+// isUnitChar reports if r is a part of a duration unit: ns, us, ms, s, m, h
+func isUnitChar(r rune) bool {
+	return r == 'n' || r == 'u' || r == 'm' || r == 's' || r == 'h'
+}
+
+func isArithChar(r rune) bool {
+	return r == '+' || r == '-' || r == '*' || r == '/' || r == '%'
+}
+
+func isListChar(r rune) bool {
+	return r == '[' || r == ']' || r == ','
+}
 
+func isOperChar(r rune) bool {
+	return r == '!' || r == '|' || r == '&' || r == '=' || r == '<' || r == '>' || r == '~'
 }
@@ -0,0 +1,94 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// matchOperExpr is one of the pattern matching operators: =~, !~. A pattern given as a string literal
+// is compiled once when the expression is parsed, other patterns are compiled on evaluation and cached
+type matchOperExpr struct {
+	exprL   exprNode
+	exprR   exprNode
+	negate  bool
+	pattern *regexp.Regexp
+	token   ParserToken
+}
+
+// compile compiles the pattern if the right operand is a string literal, the literal token is used
+// as the location of an error
+func (ex *matchOperExpr) compile(literal ParserToken) error {
+
+	lit, ok := ex.exprR.(*stringValueExpr)
+	if !ok {
+		return nil
+	}
+	if literal.tokenType != tokenT_STRVAL {
+		literal = ex.token
+	}
+
+	pattern, err := regexp.Compile(lit.val)
+	if err != nil {
+		return newParserErrorAt(ErrInvalidPattern, literal, fmt.Sprintf("invalid pattern:%s,%s", lit.val, err))
+	}
+	ex.pattern = pattern
+
+	return nil
+}
+
+func (ex *matchOperExpr) evaluate(sc *scope) (valueNode, error) {
+
+	left, right, err := evaluateOperands(sc, ex.exprL, ex.exprR)
+	if err != nil {
+		return nil, err
+	}
+	if left.isValue() == unknownValue {
+		return left, nil
+	}
+
+	if left.isValue() != stringValue || right.isValue() != stringValue {
+		return nil, mismatchError(ex.token, left, right)
+	}
+
+	var text, expr string
+	left.value(&text)
+	right.value(&expr)
+
+	pattern := ex.pattern
+	if pattern == nil {
+		if pattern, err = compilePattern(expr); err != nil {
+			return nil, newEvaluateErrorAt(ErrInvalidPattern, ex.token, fmt.Sprintf("invalid pattern:%s,%s", expr, err))
+		}
+	}
+
+	return &boolValueExpr{val: pattern.MatchString(text) != ex.negate}, nil
+}
+
+func (ex *matchOperExpr) check(tc *typeChecker) valueT {
+
+	left, right := ex.exprL.check(tc), ex.exprR.check(tc)
+	tc.expect(ex.exprL, stringValue)
+	tc.expect(ex.exprR, stringValue)
+	if left == invalidValue || right == invalidValue {
+		return boolValue
+	}
+
+	if left != stringValue || right != stringValue {
+		tc.report(ErrTypeMismatch, ex.token, "can't apply %s to %s and %s", ex.token.value, left, right)
+	}
+
+	return boolValue
+}
+
+// compilePattern returns a pattern compiled on evaluation from the cache
+func compilePattern(expr string) (*regexp.Regexp, error) {
+
+	pattern, err := compiled.get("pattern:"+expr, func() (interface{}, error) {
+		return regexp.Compile(expr)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pattern.(*regexp.Regexp), nil
+}
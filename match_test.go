@@ -0,0 +1,133 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMatch_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"jobname =~ '^daily_.*'", true, nil},
+		{"jobname !~ '^daily_.*'", false, nil},
+		{"jobname =~ '^weekly_'", false, nil},
+		{"jobname !~ '^weekly_'", true, nil},
+		{"jobname =~ '_[0-9]{2}$' && jobname=~'backup'", true, nil},
+		{"jobname =~ pattern", true, nil},
+		{"jobname =~ ('^' + 'daily')", false, EvaluateError{}},
+		{"jobname =~ ('^daily')", true, nil},
+		{"lower(upper(jobname)) =~ '^DAILY' || jobname =~ '(?i)^DAILY'", true, nil},
+		{"!(jobname =~ 'x') && '' =~ '^$'", true, nil},
+		{"'a.b' =~ '^a\\.b$'", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"jobname": "daily_backup_01",
+		"pattern": "backup",
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if in.expectedError != nil {
+			if err == nil {
+				t.Error("unexpected result:", i, "expected error")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestMatch_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+		column   int
+	}{
+		{"jobname =~ '(daily'", ErrInvalidPattern, 12},
+		{"jobname !~\n  '[a-'", ErrInvalidPattern, 3},
+		{"jobname =~ ('(daily')", ErrInvalidPattern, 9},
+		{"jobname =~ broken", ErrInvalidPattern, 9},
+		{"jobname =~ 1", ErrTypeMismatch, 9},
+		{"retries =~ 'a'", ErrTypeMismatch, 9},
+		{"jobname =~", ErrUnexpectedEnd, 11},
+		{"jobname ~ 'a'", ErrUnrecognizedToken, 9},
+		{"jobname =! 'a'", ErrUnrecognizedToken, 9},
+	}
+
+	values := map[string]interface{}{
+		"jobname": "daily",
+		"retries": 1,
+		"broken":  "a(",
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+			continue
+		}
+		if d, ok := detailOf(err); !ok || d.Column != in.column {
+			t.Error("unexpected result:", i, "column:", d.Column, "expected:", in.column)
+		}
+	}
+}
+
+func TestMatch_CompiledOnce(t *testing.T) {
+
+	prog, err := Compile("jobname =~ '^daily_' && jobname =~ pattern")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+
+	and := prog.root.(*andOperExpr)
+	if and.exprL.(*matchOperExpr).pattern == nil {
+		t.Error("unexpected result, literal pattern is not compiled")
+	}
+	if and.exprR.(*matchOperExpr).pattern != nil {
+		t.Error("unexpected result, variable pattern is compiled")
+	}
+
+	for _, p := range []string{"_01$", "_02$", "_01$"} {
+		r, err := prog.Eval(map[string]interface{}{"jobname": "daily_01", "pattern": p})
+		if err != nil || r != strings.HasSuffix(p, "1$") {
+			t.Error("unexpected result:", p, r, "error:", err)
+		}
+	}
+	if !compiled.has("pattern:_02$") {
+		t.Error("unexpected result, pattern is not cached")
+	}
+}
+
+func TestMatch_Undefined(t *testing.T) {
+
+	prog, _ := Compile("missing =~ '^$'")
+
+	r, err := prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedDefault))
+	if err != nil || r != True {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+	r, err = prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedUnknown))
+	if err != nil || r != Unknown {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+}
+
+func TestMatch_Check(t *testing.T) {
+
+	if err := Check("label_03 =~ 'a' && label_03 !~ label_03", testSchema); err != nil {
+		t.Error("unexpected result:", err)
+	}
+	if err := Check("retries =~ 'a' || label_03 !~ 1", testSchema); err == nil || len(err.(ErrorList)) != 2 {
+		t.Error("unexpected result:", err)
+	}
+	if err := Validate("a =~ '(' && b !~ '[' && c"); err == nil || len(err.(ErrorList)) != 2 {
+		t.Error("unexpected result:", err)
+	}
+}
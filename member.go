@@ -0,0 +1,82 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// memberTag is a struct tag that renames a field in expressions, a field tagged with "-" is hidden
+const memberTag = "expr"
+
+// walkPath returns the member of a value of an identifier at the path
+func walkPath(v Value, ex *identExpr, path []string) (Value, error) {
+
+	current := ex.root
+	for _, field := range path {
+
+		rv := indirect(reflect.ValueOf(v))
+		if !rv.IsValid() || (rv.Kind() != reflect.Map && rv.Kind() != reflect.Struct) {
+			return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't access field:%s of %s, it is %T", field, current, v))
+		}
+
+		next, ok := member(rv, field)
+		if !ok {
+			return nil, newEvaluateErrorAt(ErrUndefinedField, ex.token, fmt.Sprintf("undefined field:%s of %s", field, current))
+		}
+
+		v = next
+		current = current + "." + field
+	}
+
+	return v, nil
+}
+
+// member returns a value of a map key or a struct field, exported fields are matched by the tag or by the name
+func member(rv reflect.Value, name string) (Value, bool) {
+
+	switch rv.Kind() {
+	case reflect.Map:
+		{
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			val := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !val.IsValid() {
+				return nil, false
+			}
+			return val.Interface(), true
+		}
+	case reflect.Struct:
+		{
+			t := rv.Type()
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if field.PkgPath != "" {
+					continue
+				}
+				tag := field.Tag.Get(memberTag)
+				if tag == "-" {
+					continue
+				}
+				if tag == name || (tag == "" && field.Name == name) {
+					return rv.Field(i).Interface(), true
+				}
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// indirect dereferences pointers and interfaces, it returns an invalid value for nil
+func indirect(rv reflect.Value) reflect.Value {
+
+	for rv.IsValid() && (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+
+	return rv
+}
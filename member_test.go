@@ -0,0 +1,117 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type labelInfo struct {
+	PREV    bool
+	Next    bool   `expr:"NEXT"`
+	Date    string `expr:"DATE"`
+	Runs    int
+	Hidden  bool `expr:"-"`
+	private bool
+	Owner   *ownerInfo
+}
+
+type ownerInfo struct {
+	Name string
+}
+
+func TestMember_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"label_01.PREV", true, nil},
+		{"label_01.NEXT", false, nil},
+		{"label_01.DATE == '2026-10-17'", true, nil},
+		{"label_01.Runs > 2", true, nil},
+		{"label_01.Owner.Name == 'ops'", true, nil},
+		{"label_02.PREV && !label_02.NEXT", true, nil},
+		{"label_03.state.code == 'OK'", true, nil},
+		{"label_03.count + label_01.Runs == 4", true, nil},
+		{"label_04.PREV", false, nil},
+		{"label-05.PREV || label_01.PREV", true, nil},
+		{"label_06.ENDED", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"label_01": labelInfo{PREV: true, Date: "2026-10-17", Runs: 3, Owner: &ownerInfo{Name: "ops"}},
+		"label_02": &labelInfo{PREV: true},
+		"label_03": map[string]interface{}{
+			"state": map[string]interface{}{"code": "OK"},
+			"count": 1.0,
+		},
+		// a flattened dotted name takes precedence over a member
+		"label_04.PREV": false,
+		"label_04":      labelInfo{PREV: true},
+		"label-05.PREV": false,
+		"label_06":      map[string]bool{"ENDED": true},
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestMember_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+		message  string
+	}{
+		{"label_01.MISSING", ErrUndefinedField, "undefined field:MISSING of label_01,line:1,pos:0"},
+		{"label_01.Owner.Email == ''", ErrUndefinedField, "undefined field:Email of label_01.Owner"},
+		{"label_01.Hidden", ErrUndefinedField, "undefined field:Hidden of label_01"},
+		{"label_01.private", ErrUndefinedField, "undefined field:private of label_01"},
+		{"label_01.Next", ErrUndefinedField, "undefined field:Next of label_01"},
+		{"label_02.Owner.Name == ''", ErrTypeMismatch, "can't access field:Name of label_02.Owner"},
+		{"label_01.Runs.count > 1", ErrTypeMismatch, "can't access field:count of label_01.Runs, it is int"},
+		{"label_03.state.code", ErrUndefinedField, "undefined field:code of label_03.state"},
+		{"label_01.Owner", ErrUnsupportedType, "variable:label_01.Owner"},
+		{"missing.PREV", ErrUndefinedVariable, "undefined variable:missing.PREV"},
+		{"label_03. == 1", ErrEmptyMember, "empty member name in:label_03.,line:1,pos:0"},
+		{"label_01..Runs > 1", ErrEmptyMember, "empty member name in:label_01..Runs,line:1,pos:0"},
+		{"label_01.PREV && label_01.Owner..Name", ErrEmptyMember, "empty member name in:label_01.Owner..Name,line:1,pos:17"},
+	}
+
+	values := map[string]interface{}{
+		"label_01": labelInfo{Owner: &ownerInfo{}},
+		"label_02": labelInfo{},
+		"label_03": map[string]interface{}{"state": map[string]interface{}{}},
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+			continue
+		}
+		if !strings.Contains(err.Error(), in.message) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.message)
+		}
+	}
+}
+
+func TestMember_Undefined(t *testing.T) {
+
+	values := map[string]interface{}{"label_01": labelInfo{PREV: true}}
+
+	r, err := EvalContext(context.Background(), "label_01.MISSING || label_01.PREV", values, WithUndefined(UndefinedDefault))
+	if err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	prog, _ := Compile("label_01.MISSING && label_01.PREV")
+	res, err := prog.Evaluate(context.Background(), MapResolver(values), WithUndefined(UndefinedUnknown))
+	if err != nil || res != Unknown {
+		t.Error("unexpected result:", res, "error:", err)
+	}
+}
@@ -1,9 +1,14 @@
 package expr
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type valueT uint8
@@ -12,14 +17,44 @@ const (
 	boolValue   valueT = 0
 	intValue    valueT = 1
 	stringValue valueT = 2
+	floatValue  valueT = 3
+	// unknownValue is a value of an undefined variable when undefined variables are allowed
+	unknownValue  valueT = 4
+	listValue     valueT = 5
+	timeValue     valueT = 6
+	durationValue valueT = 7
 )
 
+func (v valueT) String() string {
+	switch v {
+	case boolValue:
+		return "bool"
+	case intValue:
+		return "int"
+	case stringValue:
+		return "string"
+	case floatValue:
+		return "float"
+	case unknownValue:
+		return "unknown"
+	case listValue:
+		return "list"
+	case timeValue:
+		return "time"
+	case durationValue:
+		return "duration"
+	}
+	return "unknown"
+}
+
 type exprNode interface {
-	evaluate() (bool, error)
-	isValue() valueT
+	evaluate(sc *scope) (valueNode, error)
+	check(tc *typeChecker) valueT
 }
 
 type valueNode interface {
+	exprNode
+	isValue() valueT
 	value(out interface{}) error
 }
 
@@ -27,19 +62,24 @@ type boolValueExpr struct {
 	val bool
 }
 
-func (ex *boolValueExpr) evaluate() (bool, error) {
-	return ex.val, nil
+func (ex *boolValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *boolValueExpr) isValue() valueT                       { return boolValue }
+func (ex *boolValueExpr) value(out interface{}) error {
+
+	if v, ok := out.(*bool); ok {
+		*v = ex.val
+		return nil
+	}
+
+	return newParserError("can't cast value to bool")
 }
-func (ex *boolValueExpr) isValue() valueT { return boolValue }
 
 type stringValueExpr struct {
 	val string
 }
 
-func (ex *stringValueExpr) evaluate() (bool, error) {
-	return false, newEvaluateError("can't evaluate string")
-}
-func (ex *stringValueExpr) isValue() valueT { return stringValue }
+func (ex *stringValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *stringValueExpr) isValue() valueT                       { return stringValue }
 func (ex *stringValueExpr) value(out interface{}) error {
 
 	if v, ok := out.(*string); ok {
@@ -54,10 +94,8 @@ type intValueExpr struct {
 	val int
 }
 
-func (ex *intValueExpr) evaluate() (bool, error) {
-	return false, newEvaluateError("can't evaluate")
-}
-func (ex *intValueExpr) isValue() valueT { return intValue }
+func (ex *intValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *intValueExpr) isValue() valueT                       { return intValue }
 func (ex *intValueExpr) value(out interface{}) error {
 
 	if v, ok := out.(*int); ok {
@@ -68,145 +106,517 @@ func (ex *intValueExpr) value(out interface{}) error {
 	return newParserError("can't cast value to int")
 }
 
+type floatValueExpr struct {
+	val float64
+}
+
+func (ex *floatValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *floatValueExpr) isValue() valueT                       { return floatValue }
+func (ex *floatValueExpr) value(out interface{}) error {
+
+	if v, ok := out.(*float64); ok {
+		*v = ex.val
+		return nil
+	}
+
+	return newParserError("can't cast value to float")
+}
+
+type listValueExpr struct {
+	items []valueNode
+}
+
+func (ex *listValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *listValueExpr) isValue() valueT                       { return listValue }
+func (ex *listValueExpr) value(out interface{}) error {
+
+	if v, ok := out.(*[]valueNode); ok {
+		*v = ex.items
+		return nil
+	}
+
+	return newParserError("can't cast value to list")
+}
+
+// listExpr is a list literal, its items are evaluated every time the list is evaluated
+type listExpr struct {
+	items []exprNode
+	token ParserToken
+}
+
+func (ex *listExpr) evaluate(sc *scope) (valueNode, error) {
+
+	items := make([]valueNode, len(ex.items))
+	for i, item := range ex.items {
+		v, err := sc.eval(item)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = v
+	}
+
+	return &listValueExpr{items: items}, nil
+}
+
+// indexExpr is an item of a list at the index, indexes start from 0
+type indexExpr struct {
+	expr  exprNode
+	index exprNode
+	token ParserToken
+}
+
+func (ex *indexExpr) evaluate(sc *scope) (valueNode, error) {
+
+	list, err := sc.eval(ex.expr)
+	if err != nil {
+		return nil, err
+	}
+	index, err := sc.eval(ex.index)
+	if err != nil {
+		return nil, err
+	}
+
+	// an item of an unknown list is unknown, it is settled by the operator that uses it
+	if list.isValue() == unknownValue {
+		return list, nil
+	}
+	if index = sc.settleUnary(index, intValue); index.isValue() == unknownValue {
+		return index, nil
+	}
+
+	if list.isValue() != listValue {
+		return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't index %s", list.isValue()))
+	}
+	if index.isValue() != intValue {
+		return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't index list with %s, index must be int", index.isValue()))
+	}
+
+	var items []valueNode
+	var i int
+	list.value(&items)
+	index.value(&i)
+
+	if i < 0 || i >= len(items) {
+		return nil, newEvaluateErrorAt(ErrIndexOutOfRange, ex.token, fmt.Sprintf("index out of range:%d, length:%d", i, len(items)))
+	}
+
+	return items[i], nil
+}
+
+// identExpr is a reference to a variable, it is resolved at evaluation time.
+// A dotted name is split into the root variable, the path to its member and the qualifier
+type identExpr struct {
+	name      string
+	root      string
+	path      []string
+	qualifier string
+	token     ParserToken
+}
+
+func newIdentExpr(token ParserToken, qualifiers *qualifierSet) (*identExpr, error) {
+
+	root, path, qualifier, err := splitIdent(token, qualifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identExpr{name: token.value, root: root, path: path, qualifier: qualifier, token: token}, nil
+}
+
+// members returns the path to the member with the qualifier as the last member,
+// it is used when a resolver doesn't resolve qualifiers
+func (ex *identExpr) members() []string {
+
+	if ex.qualifier == "" {
+		return ex.path
+	}
+	return append(ex.path[:len(ex.path):len(ex.path)], ex.qualifier)
+}
+
+func (ex *identExpr) evaluate(sc *scope) (valueNode, error) {
+	return sc.lookup(ex)
+}
+
+// badExpr takes place of an operand that failed to parse in the recovery mode
+type badExpr struct{}
+
+func (ex *badExpr) evaluate(sc *scope) (valueNode, error) {
+	return nil, newEvaluateError("can't evaluate invalid expression")
+}
+
 type negValueExpr struct {
-	expR exprNode
+	expR  exprNode
+	token ParserToken
 }
 
-func (ex *negValueExpr) evaluate() (bool, error) {
+func (ex *negValueExpr) evaluate(sc *scope) (valueNode, error) {
+
+	right, err := sc.eval(ex.expR)
+	if err != nil {
+		return nil, err
+	}
+
+	if right = sc.settleUnary(right, boolValue); right.isValue() == unknownValue {
+		return right, nil
+	}
 
-	if ex.expR.isValue() == boolValue {
-		right, _ := ex.expR.evaluate()
-		return !right, nil
+	if right.isValue() == boolValue {
+		var val bool
+		right.value(&val)
+		return &boolValueExpr{val: !val}, nil
 	}
 
-	return false, newEvaluateError("can't evaluate expression")
+	return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't evaluate !%s", right.isValue()))
 
 }
-func (ex *negValueExpr) isValue() valueT { return boolValue }
 
 type compareOperExpr struct {
 	exprL exprNode
 	exprR exprNode
+	token ParserToken
 }
 
-func (ex *compareOperExpr) evaluate() (bool, error) {
+func (ex *compareOperExpr) evaluate(sc *scope) (valueNode, error) {
 
-	var left, right bool
+	left, right, err := evaluateOperands(sc, ex.exprL, ex.exprR)
+	if err != nil {
+		return nil, err
+	}
+	if left.isValue() == unknownValue {
+		return left, nil
+	}
 
-	if ex.exprL.isValue() == ex.exprR.isValue() && ex.exprL.isValue() == boolValue {
-		left, _ = ex.exprL.evaluate()
-		right, _ = ex.exprR.evaluate()
+	if equal, ok := equalValues(left, right); ok {
+		return &boolValueExpr{val: equal}, nil
+	}
 
-		return left == right, nil
-	} else if ex.exprL.isValue() == ex.exprR.isValue() {
-		if ex.exprL.isValue() == intValue {
-			lvalue := 0
-			rvalue := 0
-			ex.exprL.(valueNode).value(&lvalue)
-			ex.exprR.(valueNode).value(&rvalue)
+	return nil, mismatchError(ex.token, left, right)
 
-			return lvalue == rvalue, nil
-		}
-		if ex.exprL.isValue() == stringValue {
+}
 
-			lvalue := ""
-			rvalue := ""
-			ex.exprL.(valueNode).value(&lvalue)
-			ex.exprR.(valueNode).value(&rvalue)
+// inOperExpr is a membership test, in is true if the left operand equals any item of the list,
+// not in is its negation. With the unknown policy an unknown item makes the result unknown,
+// unless another item equals the left operand
+type inOperExpr struct {
+	exprL  exprNode
+	exprR  exprNode
+	negate bool
+	token  ParserToken
+}
 
-			return lvalue == rvalue, nil
+func (ex *inOperExpr) evaluate(sc *scope) (valueNode, error) {
 
+	left, err := sc.eval(ex.exprL)
+	if err != nil {
+		return nil, err
+	}
+	right, err := sc.eval(ex.exprR)
+	if err != nil {
+		return nil, err
+	}
+
+	if right = sc.settleUnary(right, listValue); right.isValue() == unknownValue {
+		return right, nil
+	}
+	if right.isValue() != listValue {
+		return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't evaluate %s %s %s, right operand must be list", left.isValue(), ex.token.value, right.isValue()))
+	}
+
+	var items []valueNode
+	right.value(&items)
+
+	found, unknown := false, false
+	for i, item := range items {
+		lvalue, rvalue := sc.settle(left, item)
+		if lvalue.isValue() == unknownValue {
+			unknown = true
+			continue
+		}
+		equal, ok := equalValues(lvalue, rvalue)
+		if !ok {
+			return nil, newEvaluateErrorAt(ErrTypeMismatch, ex.token, fmt.Sprintf("can't evaluate %s %s list, item:%d is %s", lvalue.isValue(), ex.token.value, i, rvalue.isValue()))
+		}
+		if equal {
+			found = true
+			break
 		}
 	}
 
-	return false, newEvaluateError("can't evaluate left == right")
+	if !found && unknown {
+		return unknownValueNode, nil
+	}
 
+	return &boolValueExpr{val: found != ex.negate}, nil
 }
-func (ex *compareOperExpr) isValue() valueT { return boolValue }
 
 type orOperExpr struct {
 	exprL exprNode
 	exprR exprNode
+	token ParserToken
 }
 
-func (ex *orOperExpr) evaluate() (bool, error) {
+func (ex *orOperExpr) evaluate(sc *scope) (valueNode, error) {
+	return evaluateLogical(sc, ex.token, ex.exprL, ex.exprR, true)
+}
 
-	var left, right bool
+type andOperExpr struct {
+	exprL exprNode
+	exprR exprNode
+	token ParserToken
+}
 
-	if ex.exprL.isValue() == ex.exprR.isValue() && ex.exprL.isValue() == boolValue {
-		left, _ = ex.exprL.evaluate()
-		right, _ = ex.exprR.evaluate()
+func (ex *andOperExpr) evaluate(sc *scope) (valueNode, error) {
+	return evaluateLogical(sc, ex.token, ex.exprL, ex.exprR, false)
+}
+
+// evaluateLogical evaluates && and || with short-circuit, if the left operand equals
+// the short value it is the result and the right operand is not evaluated at all,
+// so errors in the right operand, type errors included, are not reported
+func evaluateLogical(sc *scope, token ParserToken, exprL, exprR exprNode, short bool) (valueNode, error) {
+
+	left, err := sc.eval(exprL)
+	if err != nil {
+		return nil, err
+	}
+
+	if left = sc.settleUnary(left, boolValue); left.isValue() == unknownValue {
+		return evaluateUnknownLogical(sc, token, exprR, short)
+	}
+
+	if left.isValue() != boolValue {
+		return nil, newEvaluateErrorAt(ErrTypeMismatch, token, fmt.Sprintf("can't evaluate %s %s, operand must be bool", left.isValue(), token.value))
+	}
+
+	var lvalue bool
+	left.value(&lvalue)
+	if lvalue == short {
+		return left, nil
+	}
+
+	right, err := sc.eval(exprR)
+	if err != nil {
+		return nil, err
+	}
+
+	if right = sc.settleUnary(right, boolValue); right.isValue() == unknownValue {
+		return right, nil
+	}
 
-		return left || right, nil
+	if right.isValue() != boolValue {
+		return nil, mismatchError(token, left, right)
 	}
 
-	return false, newEvaluateError("can't evaluate expression left || right")
+	return right, nil
 }
-func (ex *orOperExpr) isValue() valueT { return boolValue }
 
-type andOperExpr struct {
+type notOperExpr struct {
 	exprL exprNode
 	exprR exprNode
+	token ParserToken
 }
 
-func (ex *andOperExpr) evaluate() (bool, error) {
-
-	var left, right bool
-
-	if ex.exprL.isValue() == ex.exprR.isValue() && ex.exprL.isValue() == boolValue {
-		left, _ = ex.exprL.evaluate()
-		right, _ = ex.exprR.evaluate()
+func (ex *notOperExpr) evaluate(sc *scope) (valueNode, error) {
 
-		return left && right, nil
+	left, right, err := evaluateOperands(sc, ex.exprL, ex.exprR)
+	if err != nil {
+		return nil, err
+	}
+	if left.isValue() == unknownValue {
+		return left, nil
 	}
 
-	return false, newEvaluateError("can't evaluate expression")
+	if equal, ok := equalValues(left, right); ok {
+		return &boolValueExpr{val: !equal}, nil
+	}
 
+	return nil, mismatchError(ex.token, left, right)
 }
-func (ex *andOperExpr) isValue() valueT { return boolValue }
 
-type notOperExpr struct {
+// orderOperExpr is one of the ordering operators: <, <=, >, >=
+type orderOperExpr struct {
 	exprL exprNode
 	exprR exprNode
+	oper  TokenValue
+	token ParserToken
 }
 
-func (ex *notOperExpr) evaluate() (bool, error) {
+func (ex *orderOperExpr) evaluate(sc *scope) (valueNode, error) {
 
-	var left, right bool
+	left, right, err := evaluateOperands(sc, ex.exprL, ex.exprR)
+	if err != nil {
+		return nil, err
+	}
+	if left.isValue() == unknownValue {
+		return left, nil
+	}
 
-	if ex.exprL.isValue() == ex.exprR.isValue() && ex.exprL.isValue() == boolValue {
-		left, _ = ex.exprL.evaluate()
-		right, _ = ex.exprR.evaluate()
+	cmp, ok := orderValues(left, right)
+	if !ok {
+		return nil, mismatchError(ex.token, left, right)
+	}
 
-		return left != right, nil
-	} else if ex.exprL.isValue() == ex.exprR.isValue() {
-		if ex.exprL.isValue() == intValue {
-			lvalue := 0
-			rvalue := 0
-			ex.exprL.(valueNode).value(&lvalue)
-			ex.exprR.(valueNode).value(&rvalue)
+	var result bool
+	switch ex.oper {
+	case token_LT:
+		result = cmp < 0
+	case token_LE:
+		result = cmp <= 0
+	case token_GT:
+		result = cmp > 0
+	case token_GE:
+		result = cmp >= 0
+	}
+
+	return &boolValueExpr{val: result}, nil
+}
 
-			return lvalue != rvalue, nil
+// orderValues returns -1, 0 or 1 if left is less than, equal to or greater than right,
+// numbers are compared numerically, strings lexicographically, times as instants and durations by length,
+// ok is false if values can't be ordered
+func orderValues(left, right valueNode) (cmp int, ok bool) {
+
+	if isFloatPromoted(left, right) {
+		lvalue, rvalue := floatOf(left), floatOf(right)
+		if lvalue < rvalue {
+			return -1, true
+		}
+		if lvalue > rvalue {
+			return 1, true
 		}
-		if ex.exprL.isValue() == stringValue {
+		return 0, true
+	}
 
-			lvalue := ""
-			rvalue := ""
-			ex.exprL.(valueNode).value(&lvalue)
-			ex.exprR.(valueNode).value(&rvalue)
+	if left.isValue() != right.isValue() {
+		return 0, false
+	}
 
-			return lvalue != rvalue, nil
+	switch left.isValue() {
+	case intValue:
+		var lvalue, rvalue int
+		left.value(&lvalue)
+		right.value(&rvalue)
+		if lvalue < rvalue {
+			return -1, true
+		}
+		if lvalue > rvalue {
+			return 1, true
 		}
+		return 0, true
+	case stringValue:
+		var lvalue, rvalue string
+		left.value(&lvalue)
+		right.value(&rvalue)
+		return strings.Compare(lvalue, rvalue), true
+	case timeValue:
+		lvalue, rvalue := timeOf(left), timeOf(right)
+		if lvalue.Before(rvalue) {
+			return -1, true
+		}
+		if lvalue.After(rvalue) {
+			return 1, true
+		}
+		return 0, true
+	case durationValue:
+		lvalue, rvalue := durationOf(left), durationOf(right)
+		if lvalue < rvalue {
+			return -1, true
+		}
+		if lvalue > rvalue {
+			return 1, true
+		}
+		return 0, true
 	}
 
-	return false, newEvaluateError("can't evaluate expression")
+	return 0, false
+}
+
+func mismatchError(token ParserToken, left, right valueNode) error {
+	return newEvaluateErrorAt(ErrTypeMismatch, token, fmt.Sprintf("can't evaluate %s %s %s", left.isValue(), token.value, right.isValue()))
+}
+
+func evaluateOperands(sc *scope, exprL, exprR exprNode) (valueNode, valueNode, error) {
+
+	left, err := sc.eval(exprL)
+	if err != nil {
+		return nil, nil, err
+	}
+	right, err := sc.eval(exprR)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	left, right = sc.settle(left, right)
+
+	return left, right, nil
+}
+
+// equalValues compares two values of the same kind, ok is false if kinds differ,
+// numbers are compared as floats if any of them is a float
+func equalValues(left, right valueNode) (equal bool, ok bool) {
+
+	if isFloatPromoted(left, right) {
+		return floatOf(left) == floatOf(right), true
+	}
+
+	if left.isValue() != right.isValue() {
+		return false, false
+	}
+
+	switch left.isValue() {
+	case boolValue:
+		var lvalue, rvalue bool
+		left.value(&lvalue)
+		right.value(&rvalue)
+		return lvalue == rvalue, true
+	case intValue:
+		var lvalue, rvalue int
+		left.value(&lvalue)
+		right.value(&rvalue)
+		return lvalue == rvalue, true
+	case stringValue:
+		var lvalue, rvalue string
+		left.value(&lvalue)
+		right.value(&rvalue)
+		return lvalue == rvalue, true
+	case timeValue:
+		return timeOf(left).Equal(timeOf(right)), true
+	case durationValue:
+		return durationOf(left) == durationOf(right), true
+	}
+
+	return false, false
 }
-func (ex *notOperExpr) isValue() valueT { return boolValue }
 
 type parser struct {
-	tstream   []ParserToken
-	current   exprNode
-	variables map[string]interface{}
+	tstream    []ParserToken
+	funcs      map[string]*function
+	qualifiers *qualifierSet
+	last       ParserToken
+	recover    bool
+	errs       ErrorList
+	depth      int
+}
+
+// precedence of binary operators, operators with a higher value bind tighter,
+// unary negation binds tighter than any binary operator
+var precedence = map[TokenValue]int{
+	token_OR:       1,
+	token_AND:      2,
+	token_CMP:      3,
+	token_NOT:      3,
+	token_LT:       3,
+	token_LE:       3,
+	token_GT:       3,
+	token_GE:       3,
+	token_IN:       3,
+	token_NOTIN:    3,
+	token_MATCH:    3,
+	token_NOTMATCH: 3,
+	token_ADD:      4,
+	token_SUB:      4,
+	token_MUL:      5,
+	token_DIV:      5,
+	token_MOD:      5,
 }
 
 func (p *parser) peek() ParserToken {
@@ -224,294 +634,574 @@ func (p *parser) pop() ParserToken {
 	}
 	var value ParserToken
 	value, p.tstream = p.tstream[0], p.tstream[1:]
+	p.last = value
 
 	return value
 }
 
-func parse(tstream []ParserToken, variables map[string]interface{}) (exprNode, error) {
-
-	p := parser{tstream: tstream, current: nil, variables: variables}
+func parse(tstream []ParserToken, env *Env) (exprNode, error) {
 
-	var err error
+	p := parser{tstream: tstream, funcs: env.funcs, qualifiers: env.qualifiers}
 
-	t := p.peek()
-	fn := parseExprN
+	if p.peek().tokenType == tokenT_END {
+		return nil, nil
+	}
 
-	for t.tokenType != tokenT_END {
-		fn, err = fn(&p)
-		if err != nil {
-			return nil, err
-		}
-		t = p.peek()
+	expr, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
 
+	if next := p.peek(); next.tokenType == tokenT_RPAR {
+		return nil, newParserErrorAt(ErrUnbalancedParen, next, "unbalanced parenthesis, unexpected ')'")
+	} else if next.tokenType != tokenT_END {
+		return nil, unexpectedTokenError(next)
 	}
 
-	return p.current, nil
+	return expr, nil
 }
 
-type parserFunc func(p *parser) (parserFunc, error)
-
-func parseExprN(p *parser) (parserFunc, error) {
-
-	next := p.peek()
+// parseAll parses tokens in the recovery mode and returns all errors found, types of arguments
+// of function calls are checked like in compile. The tree built in this mode is not usable for evaluation
+func parseAll(tstream []ParserToken, env *Env) ErrorList {
 
-	if next.tokenType == tokenT_IDENT {
+	p := parser{tstream: tstream, funcs: env.funcs, qualifiers: env.qualifiers, recover: true}
 
-		token := p.pop()
-
-		if v, ok := p.variables[token.value]; ok {
-			p.current = createValueExprNode(v) // ::TODO
-		} else {
-			return nil, newParserError(fmt.Sprintf("undefined variable:%s", token.value))
-		}
+	if p.peek().tokenType == tokenT_END {
+		return nil
+	}
 
-		return parseExprExpr, nil
+	root, _ := p.parseBinary(1)
+	if root == nil {
+		return p.errs
 	}
 
-	if next.tokenType == tokenT_CONS {
+	tc := &typeChecker{free: true}
+	root.check(tc)
 
-		token := p.pop()
-		p.current = &boolValueExpr{val: token.value == "true"}
+	return append(p.errs, tc.errs...)
+}
 
-		return parseExprExpr, nil
-	}
+// fail returns an error, in the recovery mode the error is recorded
+// and a placeholder takes place of the node, so parsing goes on
+func (p *parser) fail(err error) (exprNode, error) {
 
-	if next.tokenType == tokenT_NUMBER {
-		token := p.pop()
-		val, err := strconv.Atoi(token.value)
-		if err != nil {
-			return nil, newLexerError("unexpected value, expected int")
-		}
-		p.current = &intValueExpr{val: val}
-		return parseExprExpr, nil
+	if !p.recover {
+		return nil, err
 	}
+	p.errs = append(p.errs, err)
 
-	if next.tokenType == tokenT_STRVAL {
-		token := p.pop()
+	return &badExpr{}, nil
+}
 
-		p.current = createValueExprNode(token.value)
-		return parseExprExpr, nil
+// parseBinary parses a sequence of operands separated by binary operators
+// with a precedence not lower than minPrec, all binary operators are left associative
+func (p *parser) parseBinary(minPrec int) (exprNode, error) {
 
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
 	}
 
-	if next.tokenType == tokenT_LOPER {
+	for {
+		next := p.peek()
 
-		expr, err := branchLOperatorExpr(p)
+		if p.recover && p.skipUnexpected(next) {
+			left = &badExpr{}
+			continue
+		}
+
+		prec, ok := precedence[TokenValue(next.value)]
+		if next.tokenType != tokenT_OPER || !ok || prec < minPrec {
+			return left, nil
+		}
+
+		token := p.pop()
+		operand := p.peek()
+		right, err := p.parseBinary(prec + 1)
 		if err != nil {
 			return nil, err
 		}
-		p.current = expr
 
-		return parseExprExpr, nil
+		left = produce(left, right, token)
+		if match, ok := left.(*matchOperExpr); ok {
+			if err := match.compile(operand); err != nil {
+				if left, err = p.fail(err); err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
+}
 
-	if next.tokenType == tokenT_LPAR {
+// skipUnexpected consumes a token that can't follow an operand, it is used in the recovery mode
+// to synchronize on the next operator or parenthesis
+func (p *parser) skipUnexpected(next ParserToken) bool {
 
-		expr, err := branchExpr(p)
-		if err != nil {
-			return nil, err
+	switch {
+	case next.tokenType == tokenT_INVALID:
+		// the lexer has already reported the token
+		p.pop()
+		if startsOperand(p.peek()) {
+			p.parseUnary()
 		}
-		p.current = expr
-
-		return parseExprExpr, nil
+	case next.tokenType == tokenT_RPAR && p.depth == 0:
+		p.fail(newParserErrorAt(ErrUnbalancedParen, p.pop(), "unbalanced parenthesis, unexpected ')'"))
+	case next.tokenType == tokenT_RSQR && p.depth == 0:
+		p.fail(newParserErrorAt(ErrUnbalancedParen, p.pop(), "unbalanced bracket, unexpected ']'"))
+	case next.tokenType == tokenT_COMMA && p.depth == 0:
+		p.fail(unexpectedTokenError(p.pop()))
+	case startsOperand(next):
+		// an operator is missing, the operand is parsed to report errors inside of it
+		p.fail(unexpectedTokenError(next))
+		p.parseUnary()
+	default:
+		return false
 	}
 
-	return nil, newLexerError("unexpected token")
+	return true
 }
 
-func parseExprExpr(p *parser) (parserFunc, error) {
+func startsOperand(token ParserToken) bool {
 
-	next := p.peek()
-
-	if next.tokenType == tokenT_OPER {
-		return parseOperatorExpr, nil
+	switch token.tokenType {
+	case tokenT_IDENT, tokenT_CONS, tokenT_NUMBER, tokenT_FLOAT, tokenT_DURATION, tokenT_STRVAL, tokenT_TIME, tokenT_LPAR, tokenT_LSQR, tokenT_LOPER:
+		return true
 	}
-
-	return nil, newParserError(fmt.Sprintf("unexpected token:%s,line:%d,pos:%d", next.value, next.line, next.pos))
+	return false
 }
 
-func branchLOperatorExpr(p *parser) (exprNode, error) {
-	p.pop()
-	next := p.peek()
+func (p *parser) parseUnary() (exprNode, error) {
 
-	if next.tokenType == tokenT_IDENT {
+	next := p.peek()
 
+	if next.tokenType == tokenT_LOPER {
 		p.pop()
-
-		if v, ok := p.variables[next.value]; ok {
-			return &negValueExpr{expR: createValueExprNode(v)}, nil //::TODO
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
 		}
-		return nil, newParserError(fmt.Sprintf("undefined variable:%s", next.value))
+		return &negValueExpr{expR: expr, token: next}, nil
 	}
 
-	if next.tokenType == tokenT_LPAR {
-		expr, err := branchExpr(p)
-		return &negValueExpr{expR: expr}, err
+	if next.tokenType == tokenT_OPER && next.value == string(token_SUB) {
+		p.pop()
+		// a minus directly followed by a number or a duration is a negative literal
+		if number := p.peek(); number.tokenType == tokenT_NUMBER || number.tokenType == tokenT_FLOAT || number.tokenType == tokenT_DURATION {
+			p.pop()
+			number.value = string(token_SUB) + number.value
+			return p.parseNumber(number)
+		}
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &minusValueExpr{expR: expr, token: next}, nil
 	}
 
-	return nil, newParserError(fmt.Sprintf("unexpected token:%s,line:%d,pos:%d", next.value, next.line, next.pos))
+	return p.parsePostfix()
 }
 
-func branchExpr(p *parser) (exprNode, error) {
+// parsePostfix parses an operand followed by any number of indexes
+func (p *parser) parsePostfix() (exprNode, error) {
 
-	p.pop()
-	depth := 1
-
-	tsream := []ParserToken{}
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
 
-	for depth != 0 {
-		token := p.pop()
-		if token.tokenType == tokenT_LPAR {
-			depth++
-		}
-		if token.tokenType == tokenT_RPAR {
-			depth--
+	for p.peek().tokenType == tokenT_LSQR {
+		open := p.pop()
+		p.depth++
+		index, err := p.parseBinary(1)
+		p.depth--
+		if err != nil {
+			return nil, err
 		}
-
-		if depth != 0 {
-			tsream = append(tsream, token)
+		if err := p.closeSquare(open); err != nil {
+			return p.fail(err)
 		}
-
+		expr = &indexExpr{expr: expr, index: index, token: open}
 	}
 
-	return parse(tsream, p.variables)
+	return expr, nil
 }
 
-func parseOperatorExpr(p *parser) (parserFunc, error) {
+// parseList parses items of a list literal separated by commas, the opening bracket is already consumed
+func (p *parser) parseList(open ParserToken) (exprNode, error) {
 
-	token := p.pop()
-	next := p.peek()
+	list := &listExpr{items: []exprNode{}, token: open}
+	if p.peek().tokenType == tokenT_RSQR {
+		p.pop()
+		return list, nil
+	}
 
-	if next.tokenType == tokenT_IDENT {
-		var right exprNode
+	p.depth++
+	defer func() { p.depth-- }()
 
-		if v, ok := p.variables[next.value]; ok {
-			right = createValueExprNode(v) // ::TODO
-		} else {
-			return nil, newParserError(fmt.Sprintf("undefined variable:%s", next.value))
+	for {
+		item, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
 		}
+		list.items = append(list.items, item)
 
-		p.current = produce(p.current, right, token)
-
+		if p.peek().tokenType != tokenT_COMMA {
+			break
+		}
 		p.pop()
-		return parseExprExpr, nil
 	}
 
-	if next.tokenType == tokenT_CONS {
-		right := &boolValueExpr{val: next.value == "true"}
+	if err := p.closeSquare(open); err != nil {
+		return p.fail(err)
+	}
 
-		p.current = produce(p.current, right, token)
+	return list, nil
+}
 
-		p.pop()
-		return parseExprExpr, nil
+// parseCall parses arguments of a function call separated by commas, the name is already consumed
+func (p *parser) parseCall(name ParserToken) (exprNode, error) {
 
+	fn, ok := p.funcs[name.value]
+	if !ok {
+		if _, err := p.fail(newParserErrorAt(ErrUndefinedFunction, name, fmt.Sprintf("undefined function:%s", name.value))); err != nil {
+			return nil, err
+		}
 	}
 
-	if next.tokenType == tokenT_STRVAL {
-
-		right := createValueExprNode(next.value)
-		p.current = produce(p.current, right, token)
+	open := p.pop()
+	args, starts := []exprNode{}, []ParserToken{}
+
+	if p.peek().tokenType != tokenT_RPAR {
+		p.depth++
+		for {
+			starts = append(starts, p.peek())
+			arg, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.peek().tokenType != tokenT_COMMA {
+				break
+			}
+			p.pop()
+		}
+		p.depth--
+	}
 
-		p.pop()
-		return parseExprExpr, nil
+	if closing := p.peek(); closing.tokenType != tokenT_RPAR {
+		if closing.tokenType != tokenT_END {
+			return p.fail(unexpectedTokenError(closing))
+		}
+		return p.fail(newParserErrorAt(ErrUnbalancedParen, open, "unbalanced parenthesis, missing ')' for '('"))
+	}
+	p.pop()
 
+	if !ok {
+		return &badExpr{}, nil
+	}
+	if !fn.accepts(len(args)) {
+		return p.fail(newParserErrorAt(ErrArgumentCount, name, fmt.Sprintf("function:%s expects %s, got %d", name.value, fn.arity(), len(args))))
+	}
+	if err := fn.checkLiterals(name, args, starts); err != nil {
+		return p.fail(err)
 	}
 
-	if next.tokenType == tokenT_NUMBER {
+	return &callExpr{fn: fn, args: args, token: name}, nil
+}
 
-		val, err := strconv.Atoi(next.value)
-		if err != nil {
-			return nil, newLexerError("unexpected value, expected int")
-		}
+// closeSquare consumes the closing bracket of the open one
+func (p *parser) closeSquare(open ParserToken) error {
 
-		right := &intValueExpr{val: val}
+	closing := p.peek()
+	if closing.tokenType == tokenT_RSQR {
+		p.pop()
+		return nil
+	}
+	if closing.tokenType != tokenT_END {
+		return unexpectedTokenError(closing)
+	}
 
-		p.current = produce(p.current, right, token)
+	return newParserErrorAt(ErrUnbalancedParen, open, "unbalanced bracket, missing ']' for '['")
+}
 
-		p.pop()
-		return parseExprExpr, nil
+func (p *parser) parsePrimary() (exprNode, error) {
+
+	next := p.peek()
 
+	switch next.tokenType {
+	case tokenT_IDENT:
+		{
+			p.pop()
+			if p.peek().tokenType == tokenT_LPAR && strings.HasSuffix(next.value, "-") {
+				return p.fail(newParserErrorAt(ErrAmbiguousName, next, fmt.Sprintf("ambiguous name:%s, put spaces around - to subtract", next.value)))
+			}
+			if p.peek().tokenType == tokenT_LPAR {
+				return p.parseCall(next)
+			}
+			ident, err := newIdentExpr(next, p.qualifiers)
+			if err != nil {
+				return p.fail(err)
+			}
+			return ident, nil
+		}
+	case tokenT_CONS:
+		{
+			p.pop()
+			return &boolValueExpr{val: next.value == "true"}, nil
+		}
+	case tokenT_NUMBER, tokenT_FLOAT, tokenT_DURATION:
+		{
+			p.pop()
+			return p.parseNumber(next)
+		}
+	case tokenT_TIME:
+		{
+			p.pop()
+			node, err := parseTime(next)
+			if err != nil {
+				return p.fail(err)
+			}
+			return node, nil
+		}
+	case tokenT_STRVAL:
+		{
+			p.pop()
+			return &stringValueExpr{val: strings.Trim(next.value, "'")}, nil
+		}
+	case tokenT_INVALID:
+		{
+			// the lexer has already reported the token
+			p.pop()
+			return &badExpr{}, nil
+		}
+	case tokenT_LSQR:
+		{
+			p.pop()
+			return p.parseList(next)
+		}
+	case tokenT_LPAR:
+		{
+			p.pop()
+			p.depth++
+			expr, err := p.parseBinary(1)
+			p.depth--
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.peek(); closing.tokenType != tokenT_RPAR {
+				if closing.tokenType != tokenT_END {
+					return p.fail(unexpectedTokenError(closing))
+				}
+				return p.fail(newParserErrorAt(ErrUnbalancedParen, next, "unbalanced parenthesis, missing ')' for '('"))
+			}
+			p.pop()
+			return expr, nil
+		}
+	case tokenT_END:
+		{
+			end := ParserToken{tokenType: tokenT_END, line: p.last.line, pos: p.last.pos + p.last.chars(), offset: p.last.offset + p.last.length}
+			return p.fail(newParserErrorAt(ErrUnexpectedEnd, end, "unexpected end of expression"))
+		}
 	}
 
-	if next.tokenType == tokenT_LOPER {
+	return p.fail(unexpectedTokenError(next))
+}
 
-		if right, err := branchLOperatorExpr(p); err == nil {
-			p.current = produce(p.current, right, token)
+func (p *parser) parseNumber(token ParserToken) (exprNode, error) {
 
-			return parseExprExpr, nil
-		} else {
-			return nil, newParserError(fmt.Sprintf("unexpected token:%s,line:%d,pos:%d", next.value, next.line, next.pos))
-		}
+	node, err := parseNumber(token)
+	if err != nil {
+		return p.fail(err)
 	}
+	return node, nil
+}
 
-	if next.tokenType == tokenT_LPAR {
+func parseNumber(token ParserToken) (exprNode, error) {
+
+	if token.tokenType == tokenT_DURATION {
+		return parseDuration(token)
+	}
 
-		if right, err := branchExpr(p); err == nil {
-			p.current = produce(p.current, right, token)
-			return parseExprExpr, nil
-		} else {
-			return nil, newParserError(fmt.Sprintf("unexpected token:%s,line:%d,pos:%d", next.value, next.line, next.pos))
+	if token.tokenType == tokenT_FLOAT {
+		val, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, newLexerErrorAt(ErrInvalidNumber, token, fmt.Sprintf("unexpected value:%s, expected float", token.value))
 		}
+		return &floatValueExpr{val: val}, nil
 	}
 
-	return nil, newParserError(fmt.Sprintf("unexpected token:%s,line:%d,pos:%d", next.value, next.line, next.pos))
+	val, err := strconv.Atoi(token.value)
+	if err != nil {
+		return nil, newLexerErrorAt(ErrInvalidNumber, token, fmt.Sprintf("unexpected value:%s, expected int", token.value))
+	}
+	return &intValueExpr{val: val}, nil
+}
+
+func unexpectedTokenError(token ParserToken) error {
+	return newParserErrorAt(ErrUnexpectedToken, token, fmt.Sprintf("unexpected token:%s", token.value))
 }
 
 func produce(left, right exprNode, token ParserToken) exprNode {
 
 	var current exprNode
-	if token.value == string(token_AND) {
-		current = &andOperExpr{exprL: left, exprR: right}
-	}
-	if token.value == string(token_OR) {
-		current = &orOperExpr{exprL: left, exprR: right}
-	}
-	if token.value == string(token_CMP) {
-		current = &compareOperExpr{exprL: left, exprR: right}
-	}
-	if token.value == string(token_NOT) {
-		current = &notOperExpr{exprL: left, exprR: right}
+	switch TokenValue(token.value) {
+	case token_AND:
+		current = &andOperExpr{exprL: left, exprR: right, token: token}
+	case token_OR:
+		current = &orOperExpr{exprL: left, exprR: right, token: token}
+	case token_CMP:
+		current = &compareOperExpr{exprL: left, exprR: right, token: token}
+	case token_NOT:
+		current = &notOperExpr{exprL: left, exprR: right, token: token}
+	case token_LT, token_LE, token_GT, token_GE:
+		current = &orderOperExpr{exprL: left, exprR: right, oper: TokenValue(token.value), token: token}
+	case token_MATCH, token_NOTMATCH:
+		current = &matchOperExpr{exprL: left, exprR: right, negate: token.value == string(token_NOTMATCH), token: token}
+	case token_IN, token_NOTIN:
+		current = &inOperExpr{exprL: left, exprR: right, negate: token.value == string(token_NOTIN), token: token}
+	case token_ADD, token_SUB, token_MUL, token_DIV, token_MOD:
+		current = &arithOperExpr{exprL: left, exprR: right, oper: TokenValue(token.value), token: token}
 	}
 	return current
 }
 
-func createValueExprNode(val interface{}) exprNode {
+// createValueExprNode converts a Go value to a value node, besides bool, int, float64 and string
+// it accepts all integer and float kinds and named types with a bool, numeric or string underlying kind,
+// time.Time and time.Duration, slices and arrays of them are converted to lists
+func createValueExprNode(val interface{}) (valueNode, error) {
 
-	var node exprNode
 	switch x := val.(type) {
+	case time.Time:
+		{
+			return &timeValueExpr{val: x}, nil
+		}
+	case time.Duration:
+		{
+			return &durationValueExpr{val: x}, nil
+		}
 	case bool:
 		{
-			node = &boolValueExpr{val: x}
+			return &boolValueExpr{val: x}, nil
 		}
 	case int:
 		{
-			node = &intValueExpr{val: x}
+			return &intValueExpr{val: x}, nil
+		}
+	case float64:
+		{
+			return &floatValueExpr{val: x}, nil
 		}
 	case string:
 		{
-			node = &stringValueExpr{val: strings.Trim(x, "'")}
+			return &stringValueExpr{val: strings.Trim(x, "'")}, nil
 		}
 	}
 
-	return node
+	if val == nil {
+		return nil, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrUnsupportedType}, msg: "unsupported type:nil"}
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Bool:
+		{
+			return &boolValueExpr{val: rv.Bool()}, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		{
+			v := rv.Int()
+			if v < math.MinInt || v > math.MaxInt {
+				return nil, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrOutOfRange}, msg: fmt.Sprintf("value out of range:%d", v)}
+			}
+			return &intValueExpr{val: int(v)}, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		{
+			v := rv.Uint()
+			if v > math.MaxInt {
+				return nil, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrOutOfRange}, msg: fmt.Sprintf("value out of range:%d", v)}
+			}
+			return &intValueExpr{val: int(v)}, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		{
+			return &floatValueExpr{val: rv.Float()}, nil
+		}
+	case reflect.String:
+		{
+			return &stringValueExpr{val: strings.Trim(rv.String(), "'")}, nil
+		}
+	case reflect.Slice, reflect.Array:
+		{
+			items := make([]valueNode, rv.Len())
+			for i := range items {
+				item, err := createValueExprNode(rv.Index(i).Interface())
+				if err != nil {
+					return nil, err
+				}
+				items[i] = item
+			}
+			return &listValueExpr{items: items}, nil
+		}
+	}
+
+	return nil, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrUnsupportedType}, msg: fmt.Sprintf("unsupported type:%T", val)}
 }
 
 func Eval(input string, variables map[string]interface{}) (bool, error) {
 
-	var err error
-	var tstream []ParserToken
-	if tstream, err = tokenize(input); err != nil {
+	prog, err := Compile(input)
+	if err != nil {
 		return false, err
 	}
-	var ex exprNode
-	ex, err = parse(tstream, variables)
+
+	return prog.Eval(variables)
+}
+
+// EvalContext compiles an expression and evaluates it against given variables,
+// the evaluation is aborted with ErrCanceled when the context is done
+func EvalContext(ctx context.Context, input string, variables map[string]interface{}, opts ...EvalOption) (bool, error) {
+
+	prog, err := Compile(input)
 	if err != nil {
 		return false, err
 	}
 
-	return ex.evaluate()
+	return prog.EvalContext(ctx, variables, opts...)
 }
 
+// Test validates the syntax of an expression, values of variables are not required,
+// identifiers are treated as free symbols
 func Test(input string) error {
-	return nil
+	_, err := Compile(input)
+	return err
+}
+
+// Validate checks the syntax of an expression like Test, but it doesn't stop at the first error,
+// all lexer and parser errors, ordered by their position, are returned as an ErrorList
+func Validate(input string) error {
+	return validate(input, defaultEnv)
+}
+
+func validate(input string, env *Env) error {
+
+	tokens, errs := tokenizeAll(input)
+	if len(tokens) == 0 && len(errs) == 0 {
+		errs = ErrorList{ParserError{ErrorDetail: ErrorDetail{Code: ErrEmptyExpression}, msg: "empty expression"}}
+	}
+
+	errs = append(errs, parseAll(tokens, env)...)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		left, _ := detailOf(errs[i])
+		right, _ := detailOf(errs[j])
+		return left.Offset < right.Offset
+	})
+
+	return errs
 }
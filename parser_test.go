@@ -1,7 +1,10 @@
 package expr
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -81,6 +84,20 @@ func TestEvaluateBool_Positive(t *testing.T) {
 		{"!label_03", true, nil},
 		{"!label_03 == true", true, nil},
 		{"!label_03 != true", false, nil},
+		{"label_01 || label_03 && label_03", true, nil},
+		{"label_01 || (label_03 && label_03)", true, nil},
+		{"label_03 && label_03 || label_01", true, nil},
+		{"label_03 && (label_03 || label_01)", false, nil},
+		{"!label_01 || label_02", true, nil},
+		{"label_03 == false && label_01", true, nil},
+		{"label_01 && label_03 == false", true, nil},
+		{"label_03 || label_01 == label_02 && !label_03", true, nil},
+		{"!!label_01", true, nil},
+		{"!!!label_01", false, nil},
+		{"!!(label_03)", false, nil},
+		{"label_01&&!label_03", true, nil},
+		{"label_03||!!label_01", true, nil},
+		{"label_01!=label_03", true, nil},
 	}
 
 	values := map[string]interface{}{
@@ -100,6 +117,60 @@ func TestEvaluateBool_Positive(t *testing.T) {
 	}
 }
 
+func TestEvaluate_Precedence(t *testing.T) {
+
+	pairs := [][2]string{
+		{"a || b && c", "a || (b && c)"},
+		{"a && b || c", "(a && b) || c"},
+		{"a || b && !c", "a || (b && (!c))"},
+		{"a == b && c", "(a == b) && c"},
+		{"a || b == c", "a || (b == c)"},
+		{"!a == b", "(!a) == b"},
+		{"!!a && b", "a && b"},
+		{"a && b || c && a", "(a && b) || (c && a)"},
+	}
+
+	for _, pair := range pairs {
+		for n := 0; n < 8; n++ {
+			values := map[string]interface{}{
+				"a": n&1 != 0,
+				"b": n&2 != 0,
+				"c": n&4 != 0,
+			}
+			r, err := Eval(pair[0], values)
+			if err != nil {
+				t.Error("unexpected result input:", pair[0], "error:", err)
+			}
+			e, err := Eval(pair[1], values)
+			if err != nil {
+				t.Error("unexpected result input:", pair[1], "error:", err)
+			}
+			if r != e {
+				t.Error("unexpected result:", pair[0], "value:", r, "expected:", e, "values:", values)
+			}
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	input := []string{
+		"(label_01",
+		"((label_01) || label_02",
+		"label_01)",
+		"label_01 ||",
+		"label_01 label_02",
+		"&& label_01",
+		"()",
+	}
+
+	for i, in := range input {
+		_, err := Eval(in, map[string]interface{}{"label_01": true, "label_02": false})
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error")
+		}
+	}
+}
+
 func TestEvaluateNumbers_Positive(t *testing.T) {
 	input := []testCaseExpect{
 		{"label_01 != label_04", true, nil},
@@ -111,7 +182,16 @@ func TestEvaluateNumbers_Positive(t *testing.T) {
 		{"15 != 13", true, nil},
 		{"13 == 15", false, nil},
 		{"13 == 13", true, nil},
-		{"!(13 != 13)", false, nil},
+		{"!(13 != 13)", true, nil},
+		{"label_01 > 3", true, nil},
+		{"label_01 >= 15", true, nil},
+		{"label_01 > 15", false, nil},
+		{"label_01 < label_04", false, nil},
+		{"label_04 < label_01", true, nil},
+		{"label_04 <= 7", true, nil},
+		{"label_04 <= 6", false, nil},
+		{"13 < 15 && 15 > 13", true, nil},
+		{"label_01 > label_04 == true", true, nil},
 	}
 
 	values := map[string]interface{}{
@@ -139,6 +219,9 @@ func TestEvaluateNumbers_Negative(t *testing.T) {
 		{"(label_01 != 15) == 15", false, EvaluateError{}},
 		{"13 && 15", false, EvaluateError{}},
 		{"13 || 15", false, EvaluateError{}},
+		{"label_01 > label_02", false, EvaluateError{}},
+		{"label_02 < label_03", false, EvaluateError{}},
+		{"label_01 >= 'abc'", false, EvaluateError{}},
 	}
 
 	values := map[string]interface{}{
@@ -165,12 +248,19 @@ func TestEvaluateString_Positive(t *testing.T) {
 		{"label_02 != 'test string'", true, nil},
 		{"label_03 != label_02", true, nil},
 		{"label_03 == label_02", false, nil},
+		{"label_02 < label_03", true, nil},
+		{"label_03 > label_02", true, nil},
+		{"label_01 <= label_02", true, nil},
+		{"label_02 >= 'Test String'", true, nil},
+		{"label_04 >= '2026-01-01'", true, nil},
+		{"label_04 < '2026-01-01'", false, nil},
 	}
 
 	values := map[string]interface{}{
 		"label_01": "",
 		"label_02": "Test String",
 		"label_03": "test string",
+		"label_04": "2026-10-17",
 	}
 
 	for i, in := range input {
@@ -215,3 +305,394 @@ func TestTT(t *testing.T) {
 	})
 	fmt.Println(r, err)
 }
+
+type labelState string
+type retryCount uint16
+
+func TestEvaluateNumericKinds_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"v_int8 == -8", true, nil},
+		{"v_int16 == 16", true, nil},
+		{"v_int32 == 32", true, nil},
+		{"v_int64 > 63", true, nil},
+		{"v_uint == 1", true, nil},
+		{"v_uint8 + v_uint16 == 3", true, nil},
+		{"v_uint32 * 2 == 64", true, nil},
+		{"v_uint64 == 64", true, nil},
+		{"v_float32 == 1.5", true, nil},
+		{"v_int64 > v_float32", true, nil},
+		{"v_named_string == 'ENDED'", true, nil},
+		{"v_named_uint < 5", true, nil},
+		{"v_named_bool", true, nil},
+	}
+
+	type flag bool
+
+	values := map[string]interface{}{
+		"v_int8":         int8(-8),
+		"v_int16":        int16(16),
+		"v_int32":        int32(32),
+		"v_int64":        int64(64),
+		"v_uint":         uint(1),
+		"v_uint8":        uint8(1),
+		"v_uint16":       uint16(2),
+		"v_uint32":       uint32(32),
+		"v_uint64":       uint64(64),
+		"v_float32":      float32(1.5),
+		"v_named_string": labelState("ENDED"),
+		"v_named_uint":   retryCount(4),
+		"v_named_bool":   flag(true),
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestEvaluateUnsupportedTypes_Negative(t *testing.T) {
+	input := []testCaseExpect{
+		{"v_nil", false, EvaluateError{}},
+		{"v_struct == 1", false, EvaluateError{}},
+		{"v_complex == 1", false, EvaluateError{}},
+		{"v_uint64 == 1", false, EvaluateError{}},
+		{"v_ptr == 1", false, EvaluateError{}},
+	}
+
+	num := 1
+	values := map[string]interface{}{
+		"v_nil":     nil,
+		"v_struct":  struct{}{},
+		"v_complex": complex(1, 1),
+		"v_uint64":  uint64(1 << 63),
+		"v_ptr":     &num,
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error:", in.expectedError)
+		} else if _, ok := err.(EvaluateError); !ok {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.expectedError)
+		}
+	}
+}
+
+func TestTest_Positive(t *testing.T) {
+	input := []string{
+		"label_01",
+		"undefined_01 && (undefined_02 || !undefined_03)",
+		"label_01.PREV == true || !label_02 && !(label_03.NEXT || label_04.DATE)",
+		"(status == 'OK')",
+		"retries > 3 &&\n run_date >= '2026-01-01'\n",
+		"((((a))))",
+	}
+
+	for i, in := range input {
+		if err := Test(in); err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+	}
+}
+
+func TestTest_Negative(t *testing.T) {
+	input := []struct {
+		expr     string
+		expected error
+		message  string
+	}{
+		{"", LexerError{}, "empty stream"},
+		{"   ", ParserError{}, "empty expression"},
+		{"$label", LexerError{}, "line:1"},
+		{"label == 'abc", LexerError{}, "unterminated string,line:1,position:9"},
+		{"label == 'a\nb'", LexerError{}, "unterminated string"},
+		{"(label_01 && label_02", ParserError{}, "unbalanced parenthesis, missing ')' for '(',line:1,pos:0"},
+		{"label_01 && (label_02 || (label_03)", ParserError{}, "unbalanced parenthesis, missing ')' for '(',line:1,pos:12"},
+		{"label_01 && label_02)", ParserError{}, "unbalanced parenthesis, unexpected ')',line:1,pos:20"},
+		{"label_01 &&", ParserError{}, "unexpected end of expression,line:1,pos:11"},
+		{"label_01 label_02", ParserError{}, "unexpected token:label_02,line:1,pos:9"},
+		{"(label_01 label_02)", ParserError{}, "unexpected token:label_02,line:1,pos:10"},
+		{"label_01 || ()", ParserError{}, "unexpected token:)"},
+		{"99999999999999999999 > 1", LexerError{}, "expected int"},
+	}
+
+	for i, in := range input {
+		err := Test(in.expr)
+		if err == nil {
+			t.Error("unexpected result:", i, "expected error:", in.message)
+			continue
+		}
+		if fmt.Sprintf("%T", err) != fmt.Sprintf("%T", in.expected) {
+			t.Error("unexpected result:", i, "error type:", fmt.Sprintf("%T", err), "expected:", fmt.Sprintf("%T", in.expected))
+		}
+		if !strings.Contains(err.Error(), in.message) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.message)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	input := []struct {
+		expr  string
+		codes []ErrorCode
+	}{
+		{"label_01 && (label_02 || !label_03)", nil},
+		{"", []ErrorCode{ErrEmptyExpression}},
+		{"  ", []ErrorCode{ErrEmptyExpression}},
+		{"label_01 && $label_02 || label_03 #", []ErrorCode{ErrUnexpectedChar, ErrUnexpectedChar}},
+		{"label_01 &&\n (label_02 ||\n label_03", []ErrorCode{ErrUnbalancedParen}},
+		{"(label_01 &&) || label_02)", []ErrorCode{ErrUnexpectedToken, ErrUnbalancedParen}},
+		{"label_01 label_02 && label_03 label_04", []ErrorCode{ErrUnexpectedToken, ErrUnexpectedToken}},
+		{"&& label_01 || ", []ErrorCode{ErrUnexpectedToken, ErrUnexpectedEnd}},
+		{"label_01 &= label_02 && name == 'abc", []ErrorCode{ErrUnrecognizedToken, ErrUnterminatedString}},
+		{"label_01 && ! label_02 || 99999999999999999999 > 1", []ErrorCode{ErrUnexpectedChar, ErrInvalidNumber}},
+		{"a ||| b && (c", []ErrorCode{ErrUnrecognizedToken, ErrUnbalancedParen}},
+		{"((a)) && b) && (c", []ErrorCode{ErrUnbalancedParen, ErrUnbalancedParen}},
+		{"a in [1, $2, 3] && b]", []ErrorCode{ErrUnexpectedChar, ErrUnbalancedParen}},
+		{"a in [1 2, 3 && runs[0", []ErrorCode{ErrUnbalancedParen, ErrUnexpectedToken, ErrUnbalancedParen}},
+		{"a, b", []ErrorCode{ErrUnexpectedToken, ErrUnexpectedToken}},
+	}
+
+	for i, in := range input {
+		err := Validate(in.expr)
+		if in.codes == nil {
+			if err != nil {
+				t.Error("unexpected result:", i, "error:", err)
+			}
+			continue
+		}
+		list, ok := err.(ErrorList)
+		if !ok {
+			t.Error("unexpected result:", i, "error:", err)
+			continue
+		}
+		if len(list) != len(in.codes) {
+			t.Error("unexpected result:", i, "errors:", list, "expected:", in.codes)
+			continue
+		}
+		for n, e := range list {
+			if !errors.Is(e, in.codes[n]) {
+				t.Error("unexpected result:", i, "error:", e, "expected:", in.codes[n])
+			}
+		}
+	}
+}
+
+func TestValidate_SameAsTest(t *testing.T) {
+	input := []string{
+		"label_01 &&",
+		"(label_01",
+		"label_01)",
+		"label_01 label_02",
+		"$label",
+		"label == 'abc",
+		"[1, 2",
+		"a in [1 2]",
+		"upper(1) == 'A'",
+		"weekday('x') == 1",
+	}
+
+	for i, in := range input {
+		first := Test(in)
+		list, ok := Validate(in).(ErrorList)
+		if !ok || len(list) == 0 {
+			t.Error("unexpected result:", i, "expected errors")
+			continue
+		}
+		if first.Error() != list[0].Error() {
+			t.Error("unexpected result:", i, "error:", list[0], "expected:", first)
+		}
+	}
+}
+
+func TestEvaluate_ShortCircuit(t *testing.T) {
+	input := []testCaseExpect{
+		{"label_03 && undefined", false, nil},
+		{"label_03 && 13", false, nil},
+		{"label_03 && retries / 0 == 1", false, nil},
+		{"label_01 || undefined", true, nil},
+		{"label_01 || 'string'", true, nil},
+		{"label_01 || retries / 0 == 1", true, nil},
+		{"retries != 0 && 10 / retries > 1", false, nil},
+		{"retries == 0 || 10 / retries > 1", true, nil},
+		{"(label_03 && undefined) || label_01", true, nil},
+		{"label_03 && undefined || label_01 && label_02", true, nil},
+		{"!(label_01 || undefined)", false, nil},
+	}
+
+	values := map[string]interface{}{
+		"label_01": true,
+		"label_02": true,
+		"label_03": false,
+		"retries":  0,
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestEvaluate_ShortCircuit_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"label_01 && undefined", ErrUndefinedVariable},
+		{"label_03 || undefined", ErrUndefinedVariable},
+		{"label_01 && 13", ErrTypeMismatch},
+		{"label_03 || 'string'", ErrTypeMismatch},
+		{"13 && undefined", ErrTypeMismatch},
+		{"'string' || undefined", ErrTypeMismatch},
+		{"undefined && label_03", ErrUndefinedVariable},
+		{"label_01 && retries / 0 == 1", ErrDivisionByZero},
+	}
+
+	values := map[string]interface{}{
+		"label_01": true,
+		"label_03": false,
+		"retries":  0,
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+
+	// type errors in a branch that is not evaluated are still found by Check
+	if err := Check("label_03 && 13", map[string]Type{"label_03": TypeBool}); !errors.Is(err, ErrTypeMismatch) {
+		t.Error("unexpected result:", err, "expected:", ErrTypeMismatch)
+	}
+}
+
+func TestEvaluateList_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"status in ['OK', 'ENDED', 'SKIPPED']", true, nil},
+		{"status not in ['OK', 'ENDED']", false, nil},
+		{"status in []", false, nil},
+		{"status not in []", true, nil},
+		{"retries in [1, 2.0, 3]", true, nil},
+		{"retries + 1 in [3]", true, nil},
+		{"runs[0] == 3", true, nil},
+		{"runs[2] == 5 && runs[1] < runs[2]", true, nil},
+		{"runs[retries - 1] == 4", true, nil},
+		{"[1, 2, 3][1] == 2", true, nil},
+		{"['a', ['b', 'c']][1][0] == 'b'", true, nil},
+		{"names[1] in ['x', 'y']", true, nil},
+		{"'x' in names && 'z' not in names", true, nil},
+		{"status in [label_01 && 'OK' == 'x', 'OK']", false, EvaluateError{}},
+		{"flags[0] && !flags[1]", true, nil},
+		{"status in ['A'] || status in ['OK']", true, nil},
+		{"!(status in ['A'])", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"status":   "OK",
+		"retries":  2,
+		"runs":     []int{3, 4, 5},
+		"names":    [2]string{"x", "y"},
+		"flags":    []bool{true, false},
+		"label_01": true,
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if in.expectedError != nil {
+			if err == nil {
+				t.Error("unexpected result:", i, "expected error")
+			}
+			continue
+		}
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestEvaluateList_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"runs[3] == 1", ErrIndexOutOfRange},
+		{"runs[-1] == 1", ErrIndexOutOfRange},
+		{"[][0]", ErrIndexOutOfRange},
+		{"runs['a'] == 1", ErrTypeMismatch},
+		{"status[0] == 'O'", ErrTypeMismatch},
+		{"status in 'OK'", ErrTypeMismatch},
+		{"status in [1, 2]", ErrTypeMismatch},
+		{"runs == [3, 4, 5]", ErrTypeMismatch},
+		{"runs", ErrTypeMismatch},
+		{"[1, 2", ErrUnbalancedParen},
+		{"runs[1 == 2", ErrUnbalancedParen},
+		{"runs]", ErrUnexpectedToken},
+		{"[1 2]", ErrUnexpectedToken},
+		{"[1,]", ErrUnexpectedToken},
+		{"status not ['OK']", ErrUnexpectedToken},
+		{"status in", ErrUnexpectedEnd},
+		{"maps[0] == 1", ErrUnsupportedType},
+	}
+
+	values := map[string]interface{}{
+		"status": "OK",
+		"runs":   []int{3, 4, 5},
+		"maps":   []map[string]int{{}},
+	}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+}
+
+func TestEvaluateList_Undefined(t *testing.T) {
+	input := []struct {
+		testCase string
+		policy   UndefinedPolicy
+		expected Result
+	}{
+		{"missing in ['OK']", UndefinedDefault, False},
+		{"missing in ['']", UndefinedDefault, True},
+		{"'OK' in missing", UndefinedDefault, False},
+		{"missing[0] == 0", UndefinedDefault, True},
+		{"runs[missing] == 3", UndefinedDefault, True},
+		{"missing in ['OK']", UndefinedUnknown, Unknown},
+		{"missing in []", UndefinedUnknown, False},
+		{"'OK' in missing", UndefinedUnknown, Unknown},
+		{"'OK' in ['OK', missing]", UndefinedUnknown, True},
+		{"'OK' in ['A', missing]", UndefinedUnknown, Unknown},
+		{"'OK' not in ['A', missing]", UndefinedUnknown, Unknown},
+		{"missing[0] == 3", UndefinedUnknown, Unknown},
+	}
+
+	resolver := MapResolver(map[string]interface{}{"runs": []int{3}})
+
+	for i, in := range input {
+		prog, err := Compile(in.testCase)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+			continue
+		}
+		r, err := prog.Evaluate(context.Background(), resolver, WithUndefined(in.policy))
+		if err != nil || r != in.expected {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expected, "error:", err)
+		}
+	}
+}
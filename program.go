@@ -0,0 +1,212 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Program is a compiled expression. The tree of a program holds no variable values,
+// they are resolved on every call to Eval, so a single Program can be evaluated many times
+// with different variables. Program is immutable and safe for concurrent use.
+type Program struct {
+	source string
+	root   exprNode
+}
+
+// Compile tokenizes and parses an expression into a Program, the expression can call built-in functions,
+// use Env to compile expressions with other functions
+func Compile(expr string) (*Program, error) {
+	return compile(expr, defaultEnv)
+}
+
+func compile(expr string, env *Env) (*Program, error) {
+
+	var err error
+	var tstream []ParserToken
+	if tstream, err = tokenize(expr); err != nil {
+		return nil, err
+	}
+
+	var root exprNode
+	if root, err = parse(tstream, env); err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, ParserError{ErrorDetail: ErrorDetail{Code: ErrEmptyExpression}, msg: "empty expression"}
+	}
+
+	// types of arguments of function calls are checked without a schema
+	tc := &typeChecker{free: true}
+	if root.check(tc); len(tc.errs) != 0 {
+		return nil, tc.errs[0]
+	}
+
+	return &Program{source: expr, root: root}, nil
+}
+
+// Source returns the expression the program was compiled from
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Eval evaluates the program against given variables
+func (p *Program) Eval(variables map[string]interface{}, opts ...EvalOption) (bool, error) {
+	return p.EvalResolver(context.Background(), MapResolver(variables), opts...)
+}
+
+// EvalContext evaluates the program against given variables, the evaluation is aborted
+// with ErrCanceled when the context is done
+func (p *Program) EvalContext(ctx context.Context, variables map[string]interface{}, opts ...EvalOption) (bool, error) {
+	return p.EvalResolver(ctx, MapResolver(variables), opts...)
+}
+
+// EvalResolver evaluates the program, variables are resolved on demand, only when they are reached
+// during the evaluation, each variable is resolved at most once per evaluation
+func (p *Program) EvalResolver(ctx context.Context, resolver Resolver, opts ...EvalOption) (bool, error) {
+
+	result, err := p.Evaluate(ctx, resolver, opts...)
+	if err != nil {
+		return false, err
+	}
+	if result == Unknown {
+		return false, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrUnknownResult}, msg: "result of the expression is unknown"}
+	}
+
+	return result == True, nil
+}
+
+// EvalOption changes the way a program is evaluated
+type EvalOption func(o *evalOptions)
+
+type evalOptions struct {
+	stepLimit int
+	undefined UndefinedPolicy
+}
+
+// WithStepLimit limits the number of nodes visited by a single evaluation,
+// the evaluation fails with ErrStepLimit when the limit is exceeded, zero means no limit
+func WithStepLimit(limit int) EvalOption {
+	return func(o *evalOptions) {
+		o.stepLimit = limit
+	}
+}
+
+// scope holds the state of a single evaluation
+type scope struct {
+	ctx      context.Context
+	done     <-chan struct{}
+	resolver Resolver
+	cache    map[string]Value
+	options  evalOptions
+	steps    int
+}
+
+func newScope(ctx context.Context, resolver Resolver, opts []EvalOption) *scope {
+
+	sc := &scope{ctx: ctx, done: ctx.Done(), resolver: resolver, cache: map[string]Value{}}
+	for _, opt := range opts {
+		opt(&sc.options)
+	}
+
+	return sc
+}
+
+// eval evaluates a node, every node is evaluated through it,
+// so cancellation and the step limit are checked before each step
+func (sc *scope) eval(node exprNode) (valueNode, error) {
+
+	select {
+	case <-sc.done:
+		err := sc.ctx.Err()
+		return nil, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrCanceled}, msg: fmt.Sprintf("evaluation canceled:%s", err), cause: err}
+	default:
+	}
+
+	sc.steps++
+	if sc.options.stepLimit > 0 && sc.steps > sc.options.stepLimit {
+		return nil, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrStepLimit}, msg: fmt.Sprintf("evaluation exceeded the limit of %d steps", sc.options.stepLimit)}
+	}
+
+	return node.evaluate(sc)
+}
+
+func (sc *scope) lookup(ex *identExpr) (valueNode, error) {
+
+	v, err := sc.resolve(ex)
+	if errors.Is(err, ErrUndefinedVariable) || errors.Is(err, ErrUndefinedField) {
+		if sc.options.undefined != UndefinedError {
+			return unknownValueNode, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := createValueExprNode(v)
+	if err != nil {
+		code := ErrUnsupportedType
+		if errors.Is(err, ErrOutOfRange) {
+			code = ErrOutOfRange
+		}
+		return nil, newEvaluateErrorAt(code, ex.token, fmt.Sprintf("variable:%s,%s", ex.name, err))
+	}
+
+	return node, nil
+}
+
+// resolve returns the value of an identifier. A qualified identifier is resolved with ResolveQualified
+// if the resolver implements QualifiedResolver. Otherwise a dotted name is resolved as a whole first,
+// if it's not defined, the first part of the name is resolved and the rest of the name is a path to its member
+func (sc *scope) resolve(ex *identExpr) (Value, error) {
+
+	if resolver, ok := sc.resolver.(QualifiedResolver); ok && ex.qualifier != "" {
+		v, err := sc.resolveName(ex.root+"."+ex.qualifier, ex.token, func() (Value, error) {
+			return resolver.ResolveQualified(sc.ctx, ex.root, ex.qualifier)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return walkPath(v, ex, ex.path)
+	}
+
+	v, err := sc.resolveName(ex.name, ex.token, sc.resolverOf(ex.name))
+	if len(ex.members()) == 0 || !errors.Is(err, ErrUndefinedVariable) {
+		return v, err
+	}
+
+	root, rootErr := sc.resolveName(ex.root, ex.token, sc.resolverOf(ex.root))
+	if errors.Is(rootErr, ErrUndefinedVariable) {
+		return nil, err
+	}
+	if rootErr != nil {
+		return nil, rootErr
+	}
+
+	return walkPath(root, ex, ex.members())
+}
+
+func (sc *scope) resolverOf(name string) func() (Value, error) {
+	return func() (Value, error) {
+		return sc.resolver.Resolve(sc.ctx, name)
+	}
+}
+
+// resolveName returns a value of a variable cached under the name, the value is resolved on the first use
+func (sc *scope) resolveName(name string, token ParserToken, resolve func() (Value, error)) (Value, error) {
+
+	if v, ok := sc.cache[name]; ok {
+		return v, nil
+	}
+
+	v, err := resolve()
+	if errors.Is(err, ErrUndefinedVariable) {
+		return nil, newParserErrorAt(ErrUndefinedVariable, token, fmt.Sprintf("undefined variable:%s", name))
+	}
+	if err != nil {
+		return nil, wrapEvaluateError(ErrResolveFailed, token, fmt.Sprintf("can't resolve variable:%s,%s", name, err), err)
+	}
+	sc.cache[name] = v
+
+	return v, nil
+}
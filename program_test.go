@@ -0,0 +1,168 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompile_Reuse(t *testing.T) {
+
+	prog, err := Compile("label_03 == 'ok' && (label_01 || !label_02)")
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	input := []struct {
+		values   map[string]interface{}
+		expected bool
+	}{
+		{map[string]interface{}{"label_01": true, "label_02": false, "label_03": "ok"}, true},
+		{map[string]interface{}{"label_01": true, "label_02": true, "label_03": "ok"}, true},
+		{map[string]interface{}{"label_01": false, "label_02": true, "label_03": "ok"}, false},
+		{map[string]interface{}{"label_01": true, "label_02": false, "label_03": "nok"}, false},
+	}
+
+	for i, in := range input {
+		r, err := prog.Eval(in.values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expected {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expected)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+
+	input := []string{
+		"",
+		"$label",
+		"label_01 &&",
+		"()",
+	}
+
+	for i, in := range input {
+		if _, err := Compile(in); err == nil {
+			t.Error("unexpected result:", i, "expected error")
+		}
+	}
+}
+
+func TestProgram_UndefinedVariable(t *testing.T) {
+
+	prog, err := Compile("label_01 || label_02")
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, err := prog.Eval(map[string]interface{}{"label_01": false}); err == nil {
+		t.Error("unexpected result, expected error")
+	}
+}
+
+func TestProgram_Concurrent(t *testing.T) {
+
+	prog, err := Compile("(label_01 && label_02) || (label_03 == 7)")
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				expected := n%2 == 0
+				r, err := prog.Eval(map[string]interface{}{"label_01": expected, "label_02": true, "label_03": 0})
+				if err != nil || r != expected {
+					t.Error("unexpected result:", r, "expected:", expected, "error:", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestEvalContext_Canceled(t *testing.T) {
+
+	values := map[string]interface{}{"label_01": true, "label_02": true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := EvalContext(ctx, "label_01 && label_02", values)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Error("unexpected result:", err, "expected:", ErrCanceled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	if r, err := EvalContext(ctx, "label_01 && label_02", values); err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+}
+
+func TestEvalContext_CanceledDuringEvaluation(t *testing.T) {
+
+	prog, err := Compile("label_01 && label_02 && label_03")
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Hour))
+	defer cancel()
+
+	resolved := 0
+	resolver := ResolverFunc(func(ctx context.Context, name string) (Value, error) {
+		resolved++
+		// the context is canceled while the first variable is fetched
+		cancel()
+		return true, nil
+	})
+
+	_, err = prog.EvalResolver(ctx, resolver)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Error("unexpected result:", err, "expected:", ErrCanceled)
+	}
+	if resolved != 1 {
+		t.Error("unexpected result, resolved:", resolved, "expected:", 1)
+	}
+}
+
+func TestEvalContext_StepLimit(t *testing.T) {
+
+	values := map[string]interface{}{"a": 1}
+	expr := "a" + strings.Repeat(" + a", 50) + " == 51"
+
+	if r, err := EvalContext(context.Background(), expr, values); err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	if r, err := EvalContext(context.Background(), expr, values, WithStepLimit(200)); err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	_, err := EvalContext(context.Background(), expr, values, WithStepLimit(50))
+	if !errors.Is(err, ErrStepLimit) {
+		t.Error("unexpected result:", err, "expected:", ErrStepLimit)
+	}
+	var evalErr EvaluateError
+	if !errors.As(err, &evalErr) {
+		t.Error("unexpected result:", err)
+	}
+
+	// the limit is counted per evaluation
+	prog, _ := Compile("a == 1")
+	for i := 0; i < 3; i++ {
+		if r, err := prog.Eval(values, WithStepLimit(3)); err != nil || !r {
+			t.Error("unexpected result:", r, "error:", err)
+		}
+	}
+}
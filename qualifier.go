@@ -0,0 +1,123 @@
+package expr
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// qualifierPattern is a form of a qualifier, it is a single part of a dotted name
+var qualifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// upperPattern is a form of a name that is taken for a qualifier in the strict mode
+var upperPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// qualifierSet holds qualifiers of an environment, in the strict mode the last part of a dotted name
+// in upper case must be a registered qualifier
+type qualifierSet struct {
+	names  map[string]bool
+	strict bool
+}
+
+// newQualifierSet returns a set of default qualifiers: PREV, NEXT, ODATE and ANY
+func newQualifierSet() *qualifierSet {
+	return &qualifierSet{names: map[string]bool{"PREV": true, "NEXT": true, "ODATE": true, "ANY": true}}
+}
+
+// RegisterQualifier adds a qualifier of identifiers to the environment, PREV, NEXT, ODATE and ANY are registered
+// by default. The last part of a dotted name is a qualifier only if it is registered, other parts are members.
+// Qualifiers should be registered before the environment is used, like functions
+func (e *Env) RegisterQualifier(name string) error {
+
+	if !qualifierPattern.MatchString(name) {
+		return ParserError{ErrorDetail: ErrorDetail{Code: ErrInvalidQualifier}, msg: fmt.Sprintf("invalid qualifier:%s, expected a name without dots", name)}
+	}
+	e.qualifiers.names[name] = true
+
+	return nil
+}
+
+// StrictQualifiers makes the environment reject unknown qualifiers at parse time with ErrInvalidQualifier:
+// the last part of a dotted name in upper case, like DATE in label_01.DATE, must be a registered qualifier.
+// Members of variables with names in upper case can't be the last part of a name in this mode
+func (e *Env) StrictQualifiers() {
+	e.qualifiers.strict = true
+}
+
+// QualifiedResolver is a Resolver that gets a qualifier of a variable separately from its name,
+// it is used for identifiers with a qualifier, the name is the first part of the identifier.
+// Identifiers without a qualifier are resolved with Resolve
+type QualifiedResolver interface {
+	Resolver
+	ResolveQualified(ctx context.Context, name, qualifier string) (Value, error)
+}
+
+// Identifier is a structured identifier of an expression, label_01.Owner.PREV is the variable label_01
+// with the PREV qualifier and the path to its member Owner
+type Identifier struct {
+	Name      string
+	Path      []string
+	Qualifier string
+}
+
+// ExtractIdentifiers returns identifiers of an expression in order of appearance
+func ExtractIdentifiers(expr string) ([]Identifier, error) {
+	return extractIdentifiers(expr, defaultEnv.qualifiers)
+}
+
+// ExtractIdentifiers returns identifiers of an expression like ExtractIdentifiers, qualifiers are those of the environment
+func (e *Env) ExtractIdentifiers(expr string) ([]Identifier, error) {
+	return extractIdentifiers(expr, e.qualifiers)
+}
+
+func extractIdentifiers(expr string, qualifiers *qualifierSet) ([]Identifier, error) {
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return []Identifier{}, err
+	}
+
+	identifiers := []Identifier{}
+	for n, t := range tokens {
+		if t.tokenType != tokenT_IDENT || (n+1 < len(tokens) && tokens[n+1].tokenType == tokenT_LPAR) {
+			continue
+		}
+		ex, err := newIdentExpr(t, qualifiers)
+		if err != nil {
+			return []Identifier{}, err
+		}
+		identifiers = append(identifiers, Identifier{Name: ex.root, Path: ex.path, Qualifier: ex.qualifier})
+	}
+
+	return identifiers, nil
+}
+
+// splitIdent splits a dotted name into the variable, the path to its member and the qualifier,
+// the last part of the name is the qualifier if it is registered
+func splitIdent(token ParserToken, qualifiers *qualifierSet) (root string, path []string, qualifier string, err error) {
+
+	parts := strings.Split(token.value, ".")
+	root, path = parts[0], parts[1:]
+
+	for _, part := range path {
+		if part == "" {
+			return "", nil, "", newParserErrorAt(ErrEmptyMember, token, fmt.Sprintf("empty member name in:%s", token.value))
+		}
+	}
+
+	n := len(path)
+	if n == 0 {
+		return root, path, "", nil
+	}
+
+	last := path[n-1]
+	if qualifiers.names[last] {
+		return root, path[:n-1], last, nil
+	}
+	if qualifiers.strict && upperPattern.MatchString(last) {
+		return "", nil, "", newParserErrorAt(ErrInvalidQualifier, token, fmt.Sprintf("unknown qualifier:%s of %s", last, root))
+	}
+
+	return root, path, qualifier, nil
+}
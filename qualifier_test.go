@@ -0,0 +1,183 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// labelStates resolves qualified labels by keys like label_01#PREV and counts qualified lookups
+type labelStates struct {
+	states map[string]interface{}
+	calls  int
+}
+
+func (r *labelStates) Resolve(ctx context.Context, name string) (Value, error) {
+
+	if v, ok := r.states[name]; ok {
+		return v, nil
+	}
+	return nil, ErrUndefinedVariable
+}
+
+func (r *labelStates) ResolveQualified(ctx context.Context, name, qualifier string) (Value, error) {
+
+	r.calls++
+	return r.Resolve(ctx, name+"#"+qualifier)
+}
+
+func TestQualifier_Resolve(t *testing.T) {
+	input := []testCaseExpect{
+		{"label_01.PREV", true, nil},
+		{"label_01.PREV && !label_01.NEXT", true, nil},
+		{"label_01.ODATE == '2026-10-17'", true, nil},
+		{"label_02.Owner.ANY.Name == 'ops'", false, ErrUndefinedVariable},
+		{"label_02.Owner.ANY == 'ops'", true, nil},
+		{"label_03 == 1", true, nil},
+		{"label_04.PREV", false, ErrUndefinedVariable},
+	}
+
+	resolver := &labelStates{states: map[string]interface{}{
+		"label_01#PREV":  true,
+		"label_01#NEXT":  false,
+		"label_01#ODATE": "2026-10-17",
+		"label_02#ANY":   map[string]string{"Owner": "ops"},
+		"label_03":       1,
+		// a qualified identifier isn't resolved by its full name
+		"label_04.PREV": true,
+	}}
+
+	for i, in := range input {
+		r, err := EvalResolver(context.Background(), in.testCase, resolver)
+		if !errors.Is(err, in.expectedError) {
+			t.Error("unexpected result input:", i, "error:", err, "expected:", in.expectedError)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestQualifier_Cache(t *testing.T) {
+
+	resolver := &labelStates{states: map[string]interface{}{"label_01#PREV": true, "label_01#NEXT": false}}
+
+	r, err := EvalResolver(context.Background(), "label_01.PREV && (label_01.PREV || label_01.NEXT) && !label_01.NEXT", resolver)
+	if err != nil || !r || resolver.calls != 2 {
+		t.Error("unexpected result:", r, "error:", err, "calls:", resolver.calls)
+	}
+}
+
+func TestQualifier_Members(t *testing.T) {
+
+	// names that are not registered qualifiers are members, whatever their case
+	type job struct {
+		ID   int
+		DATE string
+	}
+	values := map[string]interface{}{
+		"label_01": job{ID: 3, DATE: "2026-10-17"},
+		"label_02": map[string]interface{}{"NEXT_DAY": true},
+	}
+
+	r, err := Eval("label_01.ID == 3 && label_01.DATE == '2026-10-17' && label_02.NEXT_DAY", values)
+	if err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	identifiers, err := ExtractIdentifiers("label_01.DATE && label_02.Owner.LAST")
+	expected := []Identifier{
+		{Name: "label_01", Path: []string{"DATE"}},
+		{Name: "label_02", Path: []string{"Owner", "LAST"}},
+	}
+	if err != nil || !reflect.DeepEqual(identifiers, expected) {
+		t.Error("unexpected result:", identifiers, "error:", err)
+	}
+}
+
+func TestQualifier_Register(t *testing.T) {
+
+	env := NewEnv()
+	for _, name := range []string{"", "LAST.OK", "1ST", "LAST-OK"} {
+		if err := env.RegisterQualifier(name); !errors.Is(err, ErrInvalidQualifier) {
+			t.Error("unexpected result:", name, "error:", err)
+		}
+	}
+	if err := env.RegisterQualifier("LAST_OK"); err != nil {
+		t.Error("unexpected result:", err)
+	}
+
+	prog, err := env.Compile("label_01.LAST_OK")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+	r, err := prog.EvalResolver(context.Background(), &labelStates{states: map[string]interface{}{"label_01#LAST_OK": true}})
+	if err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	// a qualifier registered with an environment is a member for other environments
+	identifiers, err := ExtractIdentifiers("label_01.LAST_OK")
+	if err != nil || len(identifiers) != 1 || identifiers[0].Qualifier != "" {
+		t.Error("unexpected result:", identifiers, "error:", err)
+	}
+	identifiers, err = env.ExtractIdentifiers("label_01.LAST_OK")
+	if err != nil || len(identifiers) != 1 || identifiers[0].Qualifier != "LAST_OK" {
+		t.Error("unexpected result:", identifiers, "error:", err)
+	}
+}
+
+func TestQualifier_Strict(t *testing.T) {
+
+	env := NewEnv()
+	env.StrictQualifiers()
+
+	for _, in := range []string{"label_01.PREV && label_02.Owner.Name", "label_01.Id_2 == 1", "label_01"} {
+		if err := env.Validate(in); err != nil {
+			t.Error("unexpected result:", in, "error:", err)
+		}
+	}
+
+	_, err := env.Compile("label_01.PREV && label_04.DATE")
+	var perr ParserError
+	if !errors.As(err, &perr) || perr.Code != ErrInvalidQualifier || perr.Token != "label_04.DATE" {
+		t.Error("unexpected result:", err)
+	}
+	if _, err := env.ExtractIdentifiers("label_02.Owner.LAST"); !errors.Is(err, ErrInvalidQualifier) {
+		t.Error("unexpected result:", err)
+	}
+
+	// without the strict mode an unknown name is a member
+	if err := Validate("label_04.DATE"); err != nil {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestQualifier_PlainResolver(t *testing.T) {
+
+	// a resolver without qualifiers gets a qualifier as the last member of a variable
+	values := map[string]interface{}{
+		"label_01":      map[string]interface{}{"Owner": map[string]bool{"PREV": true}},
+		"label_02.NEXT": true,
+	}
+
+	r, err := Eval("label_01.Owner.PREV && label_02.NEXT", values)
+	if err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+}
+
+func TestExtractIdentifiers(t *testing.T) {
+
+	identifiers, err := ExtractIdentifiers("label_01.PREV && len(label_02.Owner.Name) > 0 || !label_03.Owner.ODATE || label_04")
+	expected := []Identifier{
+		{Name: "label_01", Path: []string{}, Qualifier: "PREV"},
+		{Name: "label_02", Path: []string{"Owner", "Name"}},
+		{Name: "label_03", Path: []string{"Owner"}, Qualifier: "ODATE"},
+		{Name: "label_04", Path: []string{}},
+	}
+	if err != nil || !reflect.DeepEqual(identifiers, expected) {
+		t.Error("unexpected result:", identifiers, "error:", err)
+	}
+}
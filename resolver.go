@@ -0,0 +1,44 @@
+package expr
+
+import "context"
+
+// Value is a value of a variable, it can be a bool, a string, any integer or float kind,
+// a named type with one of these underlying kinds, time.Time, time.Duration or a slice of them.
+// Members of maps and structs are accessed with dotted names
+type Value interface{}
+
+// Resolver provides values of variables during evaluation, Resolve is called only for identifiers
+// reached by the evaluation. If a variable doesn't exist, Resolve should return ErrUndefinedVariable.
+type Resolver interface {
+	Resolve(ctx context.Context, name string) (Value, error)
+}
+
+// ResolverFunc is an adapter to use an ordinary function as a Resolver
+type ResolverFunc func(ctx context.Context, name string) (Value, error)
+
+func (fn ResolverFunc) Resolve(ctx context.Context, name string) (Value, error) {
+	return fn(ctx, name)
+}
+
+// MapResolver resolves variables from a map, it is used by Eval
+type MapResolver map[string]interface{}
+
+func (m MapResolver) Resolve(ctx context.Context, name string) (Value, error) {
+
+	if v, ok := m[name]; ok {
+		return v, nil
+	}
+
+	return nil, ErrUndefinedVariable
+}
+
+// EvalResolver compiles an expression and evaluates it, variables are resolved on demand
+func EvalResolver(ctx context.Context, input string, resolver Resolver, opts ...EvalOption) (bool, error) {
+
+	prog, err := Compile(input)
+	if err != nil {
+		return false, err
+	}
+
+	return prog.EvalResolver(ctx, resolver, opts...)
+}
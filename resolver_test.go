@@ -0,0 +1,92 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEvalResolver_OnDemand(t *testing.T) {
+
+	values := map[string]interface{}{
+		"label_01": false,
+		"label_02": true,
+		"label_03": true,
+		"retries":  3,
+	}
+
+	input := []struct {
+		expr     string
+		expected bool
+		resolved []string
+	}{
+		{"label_01 && label_02", false, []string{"label_01"}},
+		{"label_02 || label_01", true, []string{"label_02"}},
+		{"label_01 || label_02 && label_03", true, []string{"label_01", "label_02", "label_03"}},
+		{"label_01 && undefined || retries > 2", true, []string{"label_01", "retries"}},
+		{"retries > 1 && retries < 5 && retries != 4", true, []string{"retries"}},
+	}
+
+	for i, in := range input {
+		resolved := []string{}
+		resolver := ResolverFunc(func(ctx context.Context, name string) (Value, error) {
+			resolved = append(resolved, name)
+			return MapResolver(values).Resolve(ctx, name)
+		})
+
+		r, err := EvalResolver(context.Background(), in.expr, resolver)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expected {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expected)
+		}
+		if len(resolved) != len(in.resolved) {
+			t.Error("unexpected result:", i, "resolved:", resolved, "expected:", in.resolved)
+			continue
+		}
+		for n := range resolved {
+			if resolved[n] != in.resolved[n] {
+				t.Error("unexpected result:", i, "resolved:", resolved, "expected:", in.resolved)
+			}
+		}
+	}
+}
+
+type ctxKey string
+
+func TestEvalResolver_Errors(t *testing.T) {
+
+	errStorage := errors.New("storage unavailable")
+
+	resolver := ResolverFunc(func(ctx context.Context, name string) (Value, error) {
+		switch name {
+		case "label_01":
+			return ctx.Value(ctxKey("label_01")), nil
+		case "broken":
+			return nil, errStorage
+		}
+		return nil, ErrUndefinedVariable
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey("label_01"), true)
+
+	if r, err := EvalResolver(ctx, "label_01", resolver); err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	_, err := EvalResolver(ctx, "label_01 && missing", resolver)
+	var parserErr ParserError
+	if !errors.As(err, &parserErr) || parserErr.Code != ErrUndefinedVariable || parserErr.Column != 13 {
+		t.Error("unexpected result:", err, "expected:", ErrUndefinedVariable)
+	}
+
+	_, err = EvalResolver(ctx, "label_01 && broken", resolver)
+	if !errors.Is(err, ErrResolveFailed) || !errors.Is(err, errStorage) {
+		t.Error("unexpected result:", err, "expected:", errStorage)
+	}
+
+	if _, err := MapResolver(nil).Resolve(ctx, "label_01"); !errors.Is(err, ErrUndefinedVariable) {
+		t.Error("unexpected result:", err, "expected:", ErrUndefinedVariable)
+	}
+}
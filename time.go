@@ -0,0 +1,163 @@
+package expr
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// dateLayout is a layout of date literals, a date is a midnight in UTC,
+// timestamp literals are in RFC 3339 format and carry their offset
+const dateLayout = "2006-01-02"
+
+// timeValueExpr is a point in time, times keep their location, but they are compared as instants,
+// so the same moment in different time zones is equal
+type timeValueExpr struct {
+	val time.Time
+}
+
+func (ex *timeValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *timeValueExpr) check(tc *typeChecker) valueT          { return timeValue }
+func (ex *timeValueExpr) isValue() valueT                       { return timeValue }
+func (ex *timeValueExpr) value(out interface{}) error {
+
+	if v, ok := out.(*time.Time); ok {
+		*v = ex.val
+		return nil
+	}
+
+	return newParserError("can't cast value to time")
+}
+
+type durationValueExpr struct {
+	val time.Duration
+}
+
+func (ex *durationValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *durationValueExpr) check(tc *typeChecker) valueT          { return durationValue }
+func (ex *durationValueExpr) isValue() valueT                       { return durationValue }
+func (ex *durationValueExpr) value(out interface{}) error {
+
+	if v, ok := out.(*time.Duration); ok {
+		*v = ex.val
+		return nil
+	}
+
+	return newParserError("can't cast value to duration")
+}
+
+// parseTime parses a date literal d'2006-01-02' or a timestamp literal t'2006-01-02T15:04:05Z07:00'
+func parseTime(token ParserToken) (exprNode, error) {
+
+	prefix, text := token.value[0], token.value[2:len(token.value)-1]
+
+	layout, kind := time.RFC3339, "timestamp"
+	if prefix == 'd' {
+		layout, kind = dateLayout, "date"
+	}
+
+	val, err := time.Parse(layout, text)
+	if err != nil {
+		return nil, newLexerErrorAt(ErrInvalidTime, token, fmt.Sprintf("unexpected value:%s, expected %s", token.value, kind))
+	}
+
+	return &timeValueExpr{val: val}, nil
+}
+
+func parseDuration(token ParserToken) (exprNode, error) {
+
+	val, err := time.ParseDuration(token.value)
+	if err != nil {
+		return nil, newLexerErrorAt(ErrInvalidTime, token, fmt.Sprintf("unexpected value:%s, expected duration", token.value))
+	}
+
+	return &durationValueExpr{val: val}, nil
+}
+
+// isTemporal reports if a kind is time or duration
+func isTemporal(v valueT) bool {
+	return v == timeValue || v == durationValue
+}
+
+// timeArithKind returns a kind of the result of an arithmetic operator applied to times and durations:
+// time ± duration and duration + time is time, time - time is duration, duration ± duration,
+// duration * int, int * duration and duration / int are durations, ok is false for other operands
+func timeArithKind(oper TokenValue, left, right valueT) (kind valueT, ok bool) {
+
+	switch {
+	case oper == token_ADD && left == timeValue && right == durationValue,
+		oper == token_ADD && left == durationValue && right == timeValue,
+		oper == token_SUB && left == timeValue && right == durationValue:
+		return timeValue, true
+	case oper == token_SUB && left == timeValue && right == timeValue,
+		(oper == token_ADD || oper == token_SUB) && left == durationValue && right == durationValue,
+		oper == token_MUL && left == durationValue && right == intValue,
+		oper == token_MUL && left == intValue && right == durationValue,
+		oper == token_DIV && left == durationValue && right == intValue:
+		return durationValue, true
+	}
+
+	return 0, false
+}
+
+// evaluateTimeArith applies an arithmetic operator to operands of which at least one is a time or a duration
+func evaluateTimeArith(token ParserToken, oper TokenValue, left, right valueNode) (valueNode, error) {
+
+	if _, ok := timeArithKind(oper, left.isValue(), right.isValue()); !ok {
+		return nil, mismatchError(token, left, right)
+	}
+
+	switch oper {
+	case token_ADD:
+		if left.isValue() == timeValue {
+			return &timeValueExpr{val: timeOf(left).Add(durationOf(right))}, nil
+		}
+		if right.isValue() == timeValue {
+			return &timeValueExpr{val: timeOf(right).Add(durationOf(left))}, nil
+		}
+		return &durationValueExpr{val: durationOf(left) + durationOf(right)}, nil
+	case token_SUB:
+		if left.isValue() == timeValue && right.isValue() == timeValue {
+			return &durationValueExpr{val: timeOf(left).Sub(timeOf(right))}, nil
+		}
+		if left.isValue() == timeValue {
+			return &timeValueExpr{val: timeOf(left).Add(-durationOf(right))}, nil
+		}
+		return &durationValueExpr{val: durationOf(left) - durationOf(right)}, nil
+	case token_MUL:
+		return &durationValueExpr{val: durationOf(left) * durationOf(right)}, nil
+	}
+
+	divisor := durationOf(right)
+	if divisor == 0 {
+		return nil, newEvaluateErrorAt(ErrDivisionByZero, token, "division by zero")
+	}
+
+	return &durationValueExpr{val: durationOf(left) / divisor}, nil
+}
+
+func timeOf(node valueNode) time.Time {
+
+	var val time.Time
+	node.value(&val)
+	return val
+}
+
+// durationOf returns a duration, an int is a number of nanoseconds
+func durationOf(node valueNode) time.Duration {
+
+	if node.isValue() == intValue {
+		var val int
+		node.value(&val)
+		return time.Duration(val)
+	}
+
+	var val time.Duration
+	node.value(&val)
+	return val
+}
@@ -0,0 +1,132 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTime_Positive(t *testing.T) {
+	input := []testCaseExpect{
+		{"d'2026-10-17' == d'2026-10-17'", true, nil},
+		{"d'2026-10-17' < d'2026-10-18' && d'2026-10-18' >= d'2026-10-17'", true, nil},
+		{"t'2026-10-17T10:00:00+02:00' == t'2026-10-17T08:00:00Z'", true, nil},
+		{"t'2026-10-17T01:00:00+02:00' < d'2026-10-17'", true, nil},
+		{"d'2026-10-17' + 24h == d'2026-10-18'", true, nil},
+		{"24h + d'2026-10-17' == d'2026-10-18'", true, nil},
+		{"d'2026-10-17' - 90m == t'2026-10-16T22:30:00Z'", true, nil},
+		{"d'2026-10-18' - d'2026-10-17' == 24h", true, nil},
+		{"d'2026-10-17' - d'2026-10-18' == -24h", true, nil},
+		{"1h30m == 90m && 1.5h == 90m", true, nil},
+		{"90m > 1h && 500ms < 1s && 10us < 1ms && 1ns > 0s", true, nil},
+		{"2h - 30m == 90m && 30m * 2 == 1h && 2 * 30m == 1h && 1h / 4 == 15m", true, nil},
+		{"-(30m) == -30m && -30m < 0s", true, nil},
+		{"label_04.DATE >= d'2026-10-01' && label_04.DATE < d'2026-11-01'", true, nil},
+		{"now - label_04.DATE > 2h", true, nil},
+		{"label_04.DATE + timeout == deadline", true, nil},
+		{"timeout in [30m, 1h]", true, nil},
+		{"local == t'2026-10-17T06:00:00Z'", true, nil},
+		{"runs[1] - runs[0] == 1h", true, nil},
+	}
+
+	warsaw := time.FixedZone("CEST", 2*60*60)
+	date := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	values := map[string]interface{}{
+		"label_04": map[string]interface{}{"DATE": date},
+		"now":      date.Add(3 * time.Hour),
+		"timeout":  time.Hour,
+		"deadline": date.Add(time.Hour),
+		"local":    time.Date(2026, 10, 17, 8, 0, 0, 0, warsaw),
+		"runs":     []time.Time{date, date.Add(time.Hour)},
+	}
+
+	for i, in := range input {
+		r, err := Eval(in.testCase, values)
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestTime_Negative(t *testing.T) {
+	input := []struct {
+		testCase string
+		code     ErrorCode
+	}{
+		{"d'2026-13-01' == d'2026-10-17'", ErrInvalidTime},
+		{"d'17.10.2026' == d'2026-10-17'", ErrInvalidTime},
+		{"t'2026-10-17T08:00:00' == d'2026-10-17'", ErrInvalidTime},
+		{"t'2026-10-17' == d'2026-10-17'", ErrInvalidTime},
+		{"d'2026-10-17 == d'2026-10-17'", ErrUnexpectedChar},
+		{"1h30 == 90m", ErrUnrecognizedToken},
+		{"1x == 90m", ErrUnexpectedToken},
+		{"5hm == 90m", ErrUnrecognizedToken},
+		{"9999999999h == 90m", ErrInvalidTime},
+		{"d'2026-10-17' + d'2026-10-17' == now", ErrTypeMismatch},
+		{"d'2026-10-17' + 1 == now", ErrTypeMismatch},
+		{"1h + 1 == 1h", ErrTypeMismatch},
+		{"1h * 1h == 1h", ErrTypeMismatch},
+		{"1h / 0 == 1h", ErrDivisionByZero},
+		{"d'2026-10-17' == '2026-10-17'", ErrTypeMismatch},
+		{"d'2026-10-17' < 1h", ErrTypeMismatch},
+		{"-now == now", ErrTypeMismatch},
+	}
+
+	values := map[string]interface{}{"now": time.Now()}
+
+	for i, in := range input {
+		_, err := Eval(in.testCase, values)
+		if !errors.Is(err, in.code) {
+			t.Error("unexpected result:", i, "error:", err, "expected:", in.code)
+		}
+	}
+}
+
+func TestTime_Check(t *testing.T) {
+
+	schema := map[string]Type{"start": TypeTime, "timeout": TypeDuration, "retries": TypeInt}
+
+	if err := Check("start + timeout * retries > d'2026-10-17' && -timeout < 0s && start - start == 0s", schema); err != nil {
+		t.Error("unexpected result:", err)
+	}
+	if err := Check("start + start > start || timeout > start || timeout * 1.5 == 1h", schema); err == nil || len(err.(ErrorList)) != 3 {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestTime_Functions(t *testing.T) {
+
+	env := NewEnv()
+	env.Register("later", func(t time.Time, d time.Duration) time.Time { return t.Add(d) })
+
+	prog, err := env.Compile("later(start, 1h) == t'2026-10-17T09:00:00Z' && len([start]) == 1")
+	if err != nil {
+		t.Fatal("unexpected result:", err)
+	}
+	start := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	if r, err := prog.Eval(map[string]interface{}{"start": start}); err != nil || !r {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+
+	if _, err := env.Compile("later(1h, start) == start"); !errors.Is(err, ErrTypeMismatch) {
+		t.Error("unexpected result:", err)
+	}
+}
+
+func TestTime_Undefined(t *testing.T) {
+
+	prog, _ := Compile("missing > d'2026-10-17' - 1h")
+
+	r, err := prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedDefault))
+	if err != nil || r != False {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+	r, err = prog.Evaluate(context.Background(), MapResolver(nil), WithUndefined(UndefinedUnknown))
+	if err != nil || r != Unknown {
+		t.Error("unexpected result:", r, "error:", err)
+	}
+}
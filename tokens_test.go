@@ -18,9 +18,25 @@ func TestLexer_Tokenize(t *testing.T) {
 		"||",
 		"&&",
 		"==",
+		"<",
+		"<=",
+		">",
+		">=",
+		"retry_count > 3 && run_date >= '2026-01-01'",
+		"+ - * / %",
+		"failed_runs * 2 > max_runs",
+		"elapsed % 60 == 0",
+		"-5 + (3-2)",
+		"1.5",
+		"1e-3",
+		".5",
+		"ratio >= 2.5E+10",
 		"!()",
 		"!label",
 		"label_01 || label_02 && (label_03 != false && !label_04)",
+		"status in ['OK','ENDED']",
+		"runs[0] > 1",
+		"[1, 2.5, 'x', label]",
 	}
 
 	for n, input := range in {
@@ -32,6 +48,37 @@ func TestLexer_Tokenize(t *testing.T) {
 	}
 
 }
+
+func TestLexer_NotIn(t *testing.T) {
+	input := []struct {
+		expr   string
+		tokens int
+		value  string
+		offset int
+		length int
+	}{
+		{"status not in ['OK']", 5, "not in", 7, 6},
+		{"status not\n  in ['OK']", 5, "not in", 7, 8},
+		{"status in ['OK']", 5, "in", 7, 2},
+		{"status not ['OK']", 5, "not", 7, 3},
+		{"in_progress in list", 3, "in", 12, 2},
+	}
+
+	for i, in := range input {
+		tokens, err := tokenize(in.expr)
+		if err != nil {
+			t.Error("unexpected result:", i, "error:", err)
+			continue
+		}
+		if len(tokens) != in.tokens {
+			t.Error("unexpected result:", i, "tokens:", len(tokens), "expected:", in.tokens)
+			continue
+		}
+		if oper := tokens[1]; oper.tokenType != tokenT_OPER || oper.value != in.value || oper.offset != in.offset || oper.length != in.length {
+			t.Error("unexpected result:", i, "token:", oper)
+		}
+	}
+}
 func TestLexer_TT(t *testing.T) {
 	input := "label_01.PREV == true || !label_02 && !(label_03.NEXT || label_04.DATE)"
 
@@ -109,8 +156,14 @@ func TestLexer_Tokenize_errors(t *testing.T) {
 		"label$",
 		"lab#el",
 		"! label",
-		"%label",
-		"label % label_01",
+		"label ^ label_01",
+		"label @ label_01",
+		"1.2.3",
+		"1e",
+		"1e+",
+		".",
+		"label <> label_01",
+		"label =< label_01",
 	}
 
 	for n, input := range in {
@@ -0,0 +1,160 @@
+package expr
+
+import "context"
+
+// UndefinedPolicy decides what happens when a variable used by an expression is not defined
+type UndefinedPolicy uint8
+
+const (
+	// UndefinedError fails the evaluation with ErrUndefinedVariable, it is the default policy
+	UndefinedError UndefinedPolicy = 0
+	// UndefinedDefault substitutes the zero value of the type the variable is used as:
+	// false in logical operators, 0 in arithmetic, '' when compared with a string
+	UndefinedDefault UndefinedPolicy = 1
+	// UndefinedUnknown evaluates with Kleene three-valued logic, an undefined variable is unknown,
+	// unknown && false is false, unknown || true is true, any other operation on unknown is unknown
+	UndefinedUnknown UndefinedPolicy = 2
+)
+
+// WithUndefined sets the policy for undefined variables
+func WithUndefined(policy UndefinedPolicy) EvalOption {
+	return func(o *evalOptions) {
+		o.undefined = policy
+	}
+}
+
+// Result is a three-valued result of an evaluation, Unknown is possible only with UndefinedUnknown policy
+type Result uint8
+
+const (
+	False   Result = 0
+	True    Result = 1
+	Unknown Result = 2
+)
+
+func (r Result) String() string {
+	switch r {
+	case False:
+		return "false"
+	case True:
+		return "true"
+	}
+	return "unknown"
+}
+
+// Evaluate evaluates the program like EvalResolver, but the result can be Unknown
+func (p *Program) Evaluate(ctx context.Context, resolver Resolver, opts ...EvalOption) (Result, error) {
+
+	sc := newScope(ctx, resolver, opts)
+
+	result, err := sc.eval(p.root)
+	if err != nil {
+		return False, err
+	}
+
+	result = sc.settleUnary(result, boolValue)
+	switch result.isValue() {
+	case unknownValue:
+		return Unknown, nil
+	case boolValue:
+		var val bool
+		result.value(&val)
+		if val {
+			return True, nil
+		}
+		return False, nil
+	}
+
+	return False, EvaluateError{ErrorDetail: ErrorDetail{Code: ErrTypeMismatch}, msg: "expression does not evaluate to bool"}
+}
+
+type unknownValueExpr struct{}
+
+var unknownValueNode = &unknownValueExpr{}
+
+func (ex *unknownValueExpr) evaluate(sc *scope) (valueNode, error) { return ex, nil }
+func (ex *unknownValueExpr) check(tc *typeChecker) valueT          { return invalidValue }
+func (ex *unknownValueExpr) isValue() valueT                       { return unknownValue }
+func (ex *unknownValueExpr) value(out interface{}) error {
+	return newEvaluateError("can't cast unknown value")
+}
+
+// settle applies the undefined policy to operands of a binary operator. With the default policy
+// an unknown operand becomes the zero value of the other operand kind, with the unknown policy
+// both operands become unknown, so the operator result is unknown
+func (sc *scope) settle(left, right valueNode) (valueNode, valueNode) {
+
+	lunknown, runknown := left.isValue() == unknownValue, right.isValue() == unknownValue
+	if !lunknown && !runknown {
+		return left, right
+	}
+
+	if sc.options.undefined != UndefinedDefault {
+		return unknownValueNode, unknownValueNode
+	}
+
+	switch {
+	case lunknown && runknown:
+		return zeroOf(intValue), zeroOf(intValue)
+	case lunknown:
+		return zeroOf(right.isValue()), right
+	}
+
+	return left, zeroOf(left.isValue())
+}
+
+// settleUnary applies the undefined policy to an operand that is expected to be of the kind
+func (sc *scope) settleUnary(v valueNode, kind valueT) valueNode {
+
+	if v.isValue() == unknownValue && sc.options.undefined == UndefinedDefault {
+		return zeroOf(kind)
+	}
+
+	return v
+}
+
+// evaluateUnknownLogical evaluates && and || with an unknown left operand,
+// the result is known only if the right operand equals the short value
+func evaluateUnknownLogical(sc *scope, token ParserToken, exprR exprNode, short bool) (valueNode, error) {
+
+	right, err := sc.eval(exprR)
+	if err != nil {
+		return nil, err
+	}
+
+	if right.isValue() == unknownValue {
+		return right, nil
+	}
+
+	if right.isValue() != boolValue {
+		return nil, mismatchError(token, unknownValueNode, right)
+	}
+
+	var rvalue bool
+	right.value(&rvalue)
+	if rvalue == short {
+		return right, nil
+	}
+
+	return unknownValueNode, nil
+}
+
+func zeroOf(kind valueT) valueNode {
+
+	switch kind {
+	case intValue:
+		return &intValueExpr{}
+	case floatValue:
+		return &floatValueExpr{}
+	case stringValue:
+		return &stringValueExpr{}
+	case listValue:
+		return &listValueExpr{}
+	case timeValue:
+		return &timeValueExpr{}
+	case durationValue:
+		return &durationValueExpr{}
+	}
+
+	return &boolValueExpr{}
+}
@@ -0,0 +1,116 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUndefined_Default(t *testing.T) {
+	input := []testCaseExpect{
+		{"missing", false, nil},
+		{"!missing", true, nil},
+		{"missing && label_01", false, nil},
+		{"missing || label_01", true, nil},
+		{"label_01 && missing", false, nil},
+		{"missing == false", true, nil},
+		{"missing == 0", true, nil},
+		{"missing == 0.0", true, nil},
+		{"missing == ''", true, nil},
+		{"missing != 'x'", true, nil},
+		{"retries > missing", true, nil},
+		{"missing + 2 == 2", true, nil},
+		{"-missing == 0", true, nil},
+		{"missing == other", true, nil},
+		{"missing * 2 < retries", true, nil},
+	}
+
+	values := map[string]interface{}{
+		"label_01": true,
+		"retries":  3,
+	}
+
+	for i, in := range input {
+		r, err := EvalContext(context.Background(), in.testCase, values, WithUndefined(UndefinedDefault))
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expectedValue {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expectedValue)
+		}
+	}
+}
+
+func TestUndefined_Unknown(t *testing.T) {
+	input := []struct {
+		expr     string
+		expected Result
+	}{
+		{"missing", Unknown},
+		{"!missing", Unknown},
+		{"missing && label_03", False},
+		{"label_03 && missing", False},
+		{"missing && label_01", Unknown},
+		{"label_01 && missing", Unknown},
+		{"missing || label_01", True},
+		{"label_01 || missing", True},
+		{"missing || label_03", Unknown},
+		{"label_03 || missing", Unknown},
+		{"missing && other", Unknown},
+		{"missing == 1", Unknown},
+		{"retries > missing", Unknown},
+		{"missing + 1 > 2 || retries == 3", True},
+		{"(missing || label_01) && !label_03", True},
+		{"label_01 && retries == 3", True},
+		{"label_03", False},
+	}
+
+	values := MapResolver{
+		"label_01": true,
+		"label_03": false,
+		"retries":  3,
+	}
+
+	for i, in := range input {
+		prog, err := Compile(in.expr)
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+		r, err := prog.Evaluate(context.Background(), values, WithUndefined(UndefinedUnknown))
+		if err != nil {
+			t.Error("unexpected result input:", i, "error:", err)
+		}
+		if r != in.expected {
+			t.Error("unexpected result:", i, "value:", r, "expected:", in.expected)
+		}
+	}
+}
+
+func TestUndefined_Errors(t *testing.T) {
+
+	values := map[string]interface{}{"label_01": true, "retries": 3}
+
+	_, err := EvalContext(context.Background(), "label_01 && missing", values)
+	if !errors.Is(err, ErrUndefinedVariable) {
+		t.Error("unexpected result:", err, "expected:", ErrUndefinedVariable)
+	}
+
+	_, err = EvalContext(context.Background(), "label_01 && missing", values, WithUndefined(UndefinedUnknown))
+	if !errors.Is(err, ErrUnknownResult) {
+		t.Error("unexpected result:", err, "expected:", ErrUnknownResult)
+	}
+
+	// type errors are reported even if an operand is unknown
+	_, err = EvalContext(context.Background(), "missing && retries", values, WithUndefined(UndefinedUnknown))
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Error("unexpected result:", err, "expected:", ErrTypeMismatch)
+	}
+	_, err = EvalContext(context.Background(), "'abc' > missing && retries", values, WithUndefined(UndefinedDefault))
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Error("unexpected result:", err, "expected:", ErrTypeMismatch)
+	}
+
+	if True.String() != "true" || False.String() != "false" || Unknown.String() != "unknown" {
+		t.Error("unexpected result:", True, False, Unknown)
+	}
+}